@@ -0,0 +1,155 @@
+package dynamic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateReason describes why an adaptive rate limiter changed its rate.
+type RateReason string
+
+const (
+	RateReasonOverload RateReason = "overload" // a job failed with an overload error, the rate was decreased
+	RateReasonRecovery RateReason = "recovery" // jobs succeeded without overload for an interval, the rate was increased
+)
+
+// RateEvent describes a rate change of an adaptive rate limiter.
+type RateEvent struct {
+	Time   time.Time
+	From   rate.Limit // rate before the change
+	To     rate.Limit // rate after the change
+	Reason RateReason
+	Err    error // the overload error that caused a decrease, nil for increases
+}
+
+// AdaptiveConfig configures adaptive rate limiting, see WithAdaptiveRate.
+type AdaptiveConfig struct {
+	Floor   rate.Limit // lowest rate, defaults to one job per second
+	Ceiling rate.Limit // highest rate, defaults to the limiter's rate when the pool is created
+
+	Decrease float64       // factor the rate is multiplied by on overload, between 0 and 1, defaults to 0.5
+	Increase rate.Limit    // added to the rate after every interval without overload, defaults to Floor
+	Interval time.Duration // how often the rate may increase, and how long after a decrease it may decrease again, defaults to one second
+
+	IsOverload func(error) bool // reports whether a job error signals overload, defaults to errors.Is(err, context.DeadlineExceeded)
+	OnChange   func(RateEvent)  // called for every rate change, may be nil
+}
+
+// adaptive adjusts the rate of a limiter, decreasing it multiplicatively on overload and increasing it additively otherwise.
+type adaptive struct {
+	cfg     AdaptiveConfig
+	limiter *rate.Limiter
+
+	mu           sync.Mutex
+	lastDecrease time.Time
+	succeeded    bool // a job succeeded since the last interval
+	overloaded   bool // a job was overloaded since the last interval
+}
+
+// newAdaptive fills in the defaults of the config and clamps the limiter's rate between the floor and the ceiling.
+func newAdaptive(cfg AdaptiveConfig, limiter *rate.Limiter) *adaptive {
+	if cfg.Floor <= 0 {
+		cfg.Floor = 1
+	}
+	if cfg.Ceiling <= 0 {
+		cfg.Ceiling = limiter.Limit()
+	}
+	cfg.Ceiling = max(cfg.Ceiling, cfg.Floor)
+	if cfg.Decrease <= 0 || cfg.Decrease >= 1 {
+		cfg.Decrease = 0.5
+	}
+	if cfg.Increase <= 0 {
+		cfg.Increase = cfg.Floor
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.IsOverload == nil {
+		cfg.IsOverload = func(err error) bool {
+			return errors.Is(err, context.DeadlineExceeded)
+		}
+	}
+
+	limiter.SetLimit(min(max(limiter.Limit(), cfg.Floor), cfg.Ceiling))
+	return &adaptive{cfg: cfg, limiter: limiter}
+}
+
+// run increases the rate at every interval until stop is closed.
+func (a *adaptive) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(a.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			a.tick(now)
+		}
+	}
+}
+
+// observe records the outcome of a job, decreasing the rate right away if its error signals overload.
+// Decreases are at least an interval apart, so a burst of failures caused by the same overload counts once.
+func (a *adaptive) observe(err error, now time.Time) {
+	if err != nil && !a.cfg.IsOverload(err) {
+		return
+	}
+
+	a.mu.Lock()
+	if err == nil {
+		a.succeeded = true
+		a.mu.Unlock()
+		return
+	}
+	a.overloaded = true
+	if !a.lastDecrease.IsZero() && now.Sub(a.lastDecrease) < a.cfg.Interval {
+		a.mu.Unlock()
+		return
+	}
+	a.lastDecrease = now
+	from := a.limiter.Limit()
+	to := max(from*rate.Limit(a.cfg.Decrease), a.cfg.Floor)
+	changed := a.set(now, to)
+	a.mu.Unlock()
+
+	if changed {
+		a.notify(RateEvent{Time: now, From: from, To: to, Reason: RateReasonOverload, Err: err})
+	}
+}
+
+// tick increases the rate if jobs succeeded without overload since the last interval.
+func (a *adaptive) tick(now time.Time) {
+	a.mu.Lock()
+	increase := a.succeeded && !a.overloaded
+	a.succeeded, a.overloaded = false, false
+	from := a.limiter.Limit()
+	to := min(from+a.cfg.Increase, a.cfg.Ceiling)
+	changed := increase && a.set(now, to)
+	a.mu.Unlock()
+
+	if changed {
+		a.notify(RateEvent{Time: now, From: from, To: to, Reason: RateReasonRecovery})
+	}
+}
+
+// set changes the rate of the limiter, it returns false if the rate stays the same.
+// It must be called with the mutex held, so concurrent changes are not lost.
+func (a *adaptive) set(now time.Time, to rate.Limit) bool {
+	if to == a.limiter.Limit() {
+		return false
+	}
+	a.limiter.SetLimitAt(now, to)
+	return true
+}
+
+// notify reports a rate change.
+func (a *adaptive) notify(event RateEvent) {
+	if a.cfg.OnChange != nil {
+		a.cfg.OnChange(event)
+	}
+}
@@ -0,0 +1,102 @@
+package dynamic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestAdaptive(t *testing.T) {
+	errTooManyRequests := errors.New("429 too many requests")
+	errNotFound := errors.New("404 not found")
+
+	var events []RateEvent
+	limiter := rate.NewLimiter(20, 1)
+	a := newAdaptive(AdaptiveConfig{
+		Floor:      2,
+		Ceiling:    10,
+		Increase:   1,
+		Interval:   time.Second,
+		IsOverload: func(err error) bool { return errors.Is(err, errTooManyRequests) },
+		OnChange:   func(event RateEvent) { events = append(events, event) },
+	}, limiter)
+	assert.Equal(t, rate.Limit(10), limiter.Limit()) // clamped to the ceiling
+
+	start := time.Now()
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	steps := []struct {
+		name string
+		do   func()
+		want rate.Limit
+	}{
+		{name: "Overload halves the rate", do: func() { a.observe(errTooManyRequests, at(0)) }, want: 5},
+		{name: "Overload within the interval counts once", do: func() { a.observe(errTooManyRequests, at(500*time.Millisecond)) }, want: 5},
+		{name: "No increase after an overloaded interval", do: func() { a.observe(nil, at(time.Second)); a.tick(at(time.Second)) }, want: 5},
+		{name: "Other errors are ignored", do: func() { a.observe(errNotFound, at(1500*time.Millisecond)) }, want: 5},
+		{name: "Overload after the interval decreases again", do: func() { a.observe(errTooManyRequests, at(2*time.Second)) }, want: 2.5},
+		{name: "Decrease stops at the floor", do: func() { a.observe(errTooManyRequests, at(3*time.Second)) }, want: 2},
+		{name: "No increase without successes", do: func() { a.tick(at(4 * time.Second)); a.tick(at(5 * time.Second)) }, want: 2},
+		{name: "Successful interval increases additively", do: func() { a.observe(nil, at(5*time.Second)); a.tick(at(6 * time.Second)) }, want: 3},
+	}
+	for _, step := range steps {
+		step.do()
+		assert.Equal(t, step.want, limiter.Limit(), step.name)
+	}
+
+	assert.Equal(t, []RateEvent{
+		{Time: at(0), From: 10, To: 5, Reason: RateReasonOverload, Err: errTooManyRequests},
+		{Time: at(2 * time.Second), From: 5, To: 2.5, Reason: RateReasonOverload, Err: errTooManyRequests},
+		{Time: at(3 * time.Second), From: 2.5, To: 2, Reason: RateReasonOverload, Err: errTooManyRequests},
+		{Time: at(6 * time.Second), From: 2, To: 3, Reason: RateReasonRecovery},
+	}, events)
+
+	// The rate never exceeds the ceiling.
+	for i := 0; i < 10; i++ {
+		a.observe(nil, at(7*time.Second))
+		a.tick(at(time.Duration(7+i) * time.Second))
+	}
+	assert.Equal(t, rate.Limit(10), limiter.Limit())
+}
+
+func TestNewRateLimitedAdaptiveRate(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []RateEvent
+	)
+	process := func(_ context.Context, value int) (int, error) {
+		return 0, context.DeadlineExceeded // every job times out, the upstream is overloaded
+	}
+
+	jobs := make(chan Job[int], 3)
+	for i := 0; i < 3; i++ {
+		jobs <- Job[int]{ID: i, Value: i}
+	}
+	close(jobs)
+
+	limiter := rate.NewLimiter(100, 10)
+	results := NewRateLimited(context.Background(), limiter, jobs, process, WithAdaptiveRate(AdaptiveConfig{
+		Floor: 10,
+		OnChange: func(event RateEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		},
+	}))
+	for range results {
+	}
+
+	// The three timeouts are a single overload, the rate is halved once.
+	assert.Equal(t, rate.Limit(50), limiter.Limit())
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, RateReasonOverload, events[0].Reason)
+		assert.ErrorIs(t, events[0].Err, context.DeadlineExceeded)
+	}
+}
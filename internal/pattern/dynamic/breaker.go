@@ -0,0 +1,205 @@
+package dynamic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/panics"
+)
+
+// ErrCircuitOpen is reported for jobs that were rejected because the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"    // jobs run, consecutive failures are counted
+	CircuitOpen     CircuitState = "open"      // jobs are rejected with ErrCircuitOpen until the probe interval has passed
+	CircuitHalfOpen CircuitState = "half-open" // a few probe jobs run to find out whether the downstream service recovered
+)
+
+// CircuitEvent describes a state change of a circuit breaker.
+type CircuitEvent struct {
+	Time time.Time
+	From CircuitState
+	To   CircuitState
+	Err  error // the failure that opened the circuit, nil for other changes
+}
+
+// BreakerConfig configures a circuit breaker.
+type BreakerConfig struct {
+	FailureThreshold int           // consecutive failures that open the circuit, defaults to 5
+	ProbeInterval    time.Duration // how long the circuit stays open before probe jobs are let through, defaults to one second
+	Probes           int           // probe jobs let through while half-open, all must succeed to close the circuit, defaults to 1
+
+	IsFailure func(error) bool   // reports whether a job error counts as a failure, defaults to any error but context.Canceled
+	OnChange  func(CircuitEvent) // called for every state change, may be nil
+}
+
+// CircuitBreaker stops calling a failing downstream service, so jobs fail fast instead of piling up on it.
+// It opens after a number of consecutive failures, rejects all jobs for the probe interval,
+// then lets a few probe jobs through and closes again once they all succeed, or opens again if one fails.
+type CircuitBreaker struct {
+	cfg BreakerConfig
+
+	mu         sync.Mutex
+	state      CircuitState
+	generation uint64 // increases with every state change, so outcomes of jobs let through in an earlier state are ignored
+	failures   int    // consecutive failures while closed
+	openedAt   time.Time
+	probes     int // probe jobs in flight while half-open
+	successes  int // successful probe jobs while half-open
+}
+
+// NewCircuitBreaker creates a closed circuit breaker.
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.ProbeInterval <= 0 {
+		cfg.ProbeInterval = time.Second
+	}
+	if cfg.Probes <= 0 {
+		cfg.Probes = 1
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = func(err error) bool {
+			return err != nil && !errors.Is(err, context.Canceled)
+		}
+	}
+	return &CircuitBreaker{cfg: cfg, state: CircuitClosed}
+}
+
+// State returns the current state of the circuit.
+// An open circuit whose probe interval has passed is reported as open until the next job is let through as a probe.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// Protect wraps a ProcessFunc so it is only called while the circuit breaker lets jobs through,
+// jobs that are rejected fail with ErrCircuitOpen. Panics are recovered into a PanicError and count as failures.
+func Protect[T any, U any](b *CircuitBreaker, processFunc ProcessFunc[T, U]) ProcessFunc[T, U] {
+	return func(ctx context.Context, value T) (U, error) {
+		generation, err := b.allow(time.Now())
+		if err != nil {
+			var zero U
+			return zero, err
+		}
+		result, err := panics.Process(ctx, processFunc, value)
+		b.record(generation, err, time.Now())
+		return result, err
+	}
+}
+
+// WithCircuitBreaker protects the ProcessFunc with a circuit breaker, see Protect.
+// While the circuit is open, jobs are rejected with ErrCircuitOpen as soon as they are read from the jobs channel,
+// without waiting for the rate limiter, so they do not use up its tokens.
+func WithCircuitBreaker(b *CircuitBreaker) Option {
+	return func(c *config) {
+		c.breaker = b
+	}
+}
+
+// isOpen reports whether the circuit is open and the probe interval has not passed yet, false for a nil breaker.
+func (b *CircuitBreaker) isOpen(now time.Time) bool {
+	if b == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state == CircuitOpen && now.Sub(b.openedAt) < b.cfg.ProbeInterval
+}
+
+// allow lets a job through, moving an open circuit to half-open once the probe interval has passed.
+// It returns the generation the outcome of the job must be recorded with, or ErrCircuitOpen if the job is rejected.
+func (b *CircuitBreaker) allow(now time.Time) (uint64, error) {
+	b.mu.Lock()
+	var event *CircuitEvent
+	if b.state == CircuitOpen && now.Sub(b.openedAt) >= b.cfg.ProbeInterval {
+		event = b.transition(now, CircuitHalfOpen, nil)
+	}
+	switch {
+	case b.state == CircuitOpen:
+		b.mu.Unlock()
+		return 0, ErrCircuitOpen
+	case b.state == CircuitHalfOpen && b.probes+b.successes >= b.cfg.Probes:
+		b.mu.Unlock()
+		b.notify(event)
+		return 0, ErrCircuitOpen
+	case b.state == CircuitHalfOpen:
+		b.probes++
+	}
+	generation := b.generation
+	b.mu.Unlock()
+
+	b.notify(event)
+	return generation, nil
+}
+
+// record counts the outcome of a job let through by allow, opening or closing the circuit as needed.
+// Errors that are not failures, such as cancellations, neither count as failures nor as successes.
+func (b *CircuitBreaker) record(generation uint64, err error, now time.Time) {
+	failed := b.cfg.IsFailure(err)
+	succeeded := err == nil && !failed
+
+	b.mu.Lock()
+	if generation != b.generation {
+		b.mu.Unlock()
+		return
+	}
+	var event *CircuitEvent
+	switch b.state {
+	case CircuitClosed:
+		switch {
+		case failed:
+			b.failures++
+			if b.failures >= b.cfg.FailureThreshold {
+				event = b.transition(now, CircuitOpen, err)
+			}
+		case succeeded:
+			b.failures = 0
+		}
+	case CircuitHalfOpen:
+		b.probes--
+		switch {
+		case failed:
+			event = b.transition(now, CircuitOpen, err)
+		case succeeded:
+			b.successes++
+			if b.successes >= b.cfg.Probes {
+				event = b.transition(now, CircuitClosed, nil)
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(event)
+}
+
+// transition changes the state of the circuit and returns the event describing the change.
+// It must be called with the mutex held.
+func (b *CircuitBreaker) transition(now time.Time, to CircuitState, err error) *CircuitEvent {
+	event := &CircuitEvent{Time: now, From: b.state, To: to, Err: err}
+	b.state = to
+	b.generation++
+	b.failures, b.probes, b.successes = 0, 0, 0
+	if to == CircuitOpen {
+		b.openedAt = now
+	}
+	return event
+}
+
+// notify reports a state change, if there is one.
+func (b *CircuitBreaker) notify(event *CircuitEvent) {
+	if event != nil && b.cfg.OnChange != nil {
+		b.cfg.OnChange(*event)
+	}
+}
@@ -0,0 +1,136 @@
+package dynamic
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+var errUnavailable = errors.New("service unavailable")
+
+func TestCircuitBreaker(t *testing.T) {
+	var events []CircuitEvent
+	b := NewCircuitBreaker(BreakerConfig{
+		FailureThreshold: 2,
+		ProbeInterval:    time.Second,
+		Probes:           2,
+		OnChange:         func(event CircuitEvent) { events = append(events, event) },
+	})
+	start := time.Now()
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	run := func(now time.Time, err error) error {
+		generation, allowErr := b.allow(now)
+		if allowErr != nil {
+			return allowErr
+		}
+		b.record(generation, err, now)
+		return nil
+	}
+
+	// A success resets the consecutive failures, cancellations do not count.
+	require.NoError(t, run(at(0), errUnavailable))
+	require.NoError(t, run(at(0), nil))
+	require.NoError(t, run(at(0), errUnavailable))
+	require.NoError(t, run(at(0), context.Canceled))
+	assert.Equal(t, CircuitClosed, b.State())
+
+	// The second consecutive failure opens the circuit, jobs are rejected until the probe interval has passed.
+	require.NoError(t, run(at(0), errUnavailable))
+	assert.Equal(t, CircuitOpen, b.State())
+	assert.ErrorIs(t, run(at(500*time.Millisecond), nil), ErrCircuitOpen)
+	assert.True(t, b.isOpen(at(500*time.Millisecond)))
+	assert.False(t, b.isOpen(at(time.Second)))
+
+	// A failed probe opens the circuit again, for another probe interval.
+	require.NoError(t, run(at(time.Second), errUnavailable))
+	assert.Equal(t, CircuitOpen, b.State())
+	assert.ErrorIs(t, run(at(1500*time.Millisecond), nil), ErrCircuitOpen)
+
+	// Only two probes are let through, the circuit closes once both succeed.
+	first, err := b.allow(at(2 * time.Second))
+	require.NoError(t, err)
+	second, err := b.allow(at(2 * time.Second))
+	require.NoError(t, err)
+	_, err = b.allow(at(2 * time.Second))
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	b.record(first, nil, at(2*time.Second))
+	assert.Equal(t, CircuitHalfOpen, b.State())
+	b.record(second, nil, at(2*time.Second))
+	assert.Equal(t, CircuitClosed, b.State())
+
+	// Outcomes of jobs let through before the last state change are ignored.
+	b.record(first, errUnavailable, at(2*time.Second))
+	b.record(first, errUnavailable, at(2*time.Second))
+	assert.Equal(t, CircuitClosed, b.State())
+
+	var got []CircuitState
+	for _, event := range events {
+		got = append(got, event.From, event.To)
+	}
+	assert.Equal(t, []CircuitState{
+		CircuitClosed, CircuitOpen,
+		CircuitOpen, CircuitHalfOpen,
+		CircuitHalfOpen, CircuitOpen,
+		CircuitOpen, CircuitHalfOpen,
+		CircuitHalfOpen, CircuitClosed,
+	}, got)
+	assert.ErrorIs(t, events[0].Err, errUnavailable)
+	assert.Equal(t, at(0), events[0].Time)
+}
+
+func TestProtect(t *testing.T) {
+	b := NewCircuitBreaker(BreakerConfig{FailureThreshold: 1, ProbeInterval: time.Hour})
+	process := Protect(b, func(_ context.Context, value int) (int, error) {
+		panic("boom")
+	})
+
+	_, err := process(context.Background(), 1)
+	var panicErr *PanicError
+	assert.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, CircuitOpen, b.State(), "a panic counts as a failure")
+
+	_, err = process(context.Background(), 2)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestNewRateLimitedCircuitBreaker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	var calls atomic.Int32
+	process := func(_ context.Context, _ int) (int, error) {
+		calls.Add(1)
+		return 0, errUnavailable
+	}
+
+	jobs := make(chan Job[int], 10)
+	for i := 0; i < 10; i++ {
+		jobs <- Job[int]{ID: i, Value: i}
+	}
+	close(jobs)
+
+	// Only one job runs at a time, so the circuit opens before later jobs are read.
+	b := NewCircuitBreaker(BreakerConfig{FailureThreshold: 3, ProbeInterval: time.Hour})
+	results := NewRateLimited(ctx, rate.NewLimiter(rate.Inf, 1), jobs, process, WithCircuitBreaker(b), WithOrderedResults(1))
+
+	var got []error
+	for result := range results {
+		got = append(got, result.Err)
+	}
+	require.Len(t, got, 10)
+	for i, err := range got {
+		if i < 3 {
+			assert.ErrorIs(t, err, errUnavailable)
+			continue
+		}
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+	}
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, CircuitOpen, b.State())
+}
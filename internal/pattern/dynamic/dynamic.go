@@ -2,10 +2,17 @@ package dynamic
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"sync"
+	"time"
 
 	"golang.org/x/time/rate"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/errmode"
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/panics"
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/reorder"
 )
 
 // Job holds information about each job.
@@ -24,15 +31,94 @@ type Result[T any, U any] struct {
 // ProcessFunc defines a function type for processing a value of type T to produce a value of type U, in a context-aware manner.
 type ProcessFunc[T any, U any] func(context.Context, T) (U, error)
 
+// ErrNotRun is wrapped by the errors of jobs that were taken from the jobs channel but never ran,
+// along with the cause of the context, or the error of the limiter that could not allow the job.
+var ErrNotRun = errors.New("job never ran")
+
 // NewRateLimited creates a rate-limited worker pool.
-func NewRateLimited[T any, U any](ctx context.Context, limiter *rate.Limiter, jobs <-chan Job[T], processFunc ProcessFunc[T, U]) <-chan Result[T, U] {
+// Every job runs in its own goroutine, use WithMaxInFlight to also bound how many jobs run at the same time.
+// With WithKeyedLimiter, the goroutine is started before the job waits for its key, see WithMaxKeyWaiters.
+// Waiting for the limiter respects the context: once it is cancelled, a job that was taken from the jobs channel
+// but never ran is reported with an error wrapping ErrNotRun and the context's cause.
+// Jobs still in the jobs channel are left untouched.
+func NewRateLimited[T any, U any](ctx context.Context, limiter *rate.Limiter, jobs <-chan Job[T], processFunc ProcessFunc[T, U], opts ...Option) <-chan Result[T, U] {
 	results := make(chan Result[T, U], limiter.Burst())
 
+	cfg := newConfig(opts)
+	ctx, errs := errmode.New(ctx, cfg.errorMode, cfg.errorReport)
+	bound := newInFlight[T](cfg)
+	keys := newKeyGate[T](cfg)
+	var adapt *adaptive
+	stopAdapting := make(chan struct{})
+	if cfg.adaptive != nil {
+		adapt = newAdaptive(*cfg.adaptive, limiter)
+		go adapt.run(stopAdapting)
+	}
+	var order *reorder.Buffer[Result[T, U]]
+	if cfg.orderWindow > 0 {
+		order = reorder.New(cfg.orderWindow, func(result Result[T, U]) {
+			results <- result
+		})
+	}
+	// deliver sends a result, holding it back until all earlier results are sent if results are ordered.
+	deliver := func(seq uint64, result Result[T, U]) {
+		if order != nil {
+			order.Release(seq, result)
+			return
+		}
+		results <- result
+	}
+
+	if cfg.breaker != nil {
+		processFunc = Protect(cfg.breaker, processFunc)
+	}
+
+	// reject reports a job that failed without being processed.
+	reject := func(job Job[T], queued time.Time, err error) Result[T, U] {
+		cfg.metrics.JobStarted(time.Since(queued))
+		cfg.metrics.JobFinished(0, err)
+		errs.Record(job.ID, err)
+		return Result[T, U]{Job: job, Err: err}
+	}
+	// notRun reports a job that was taken from the jobs channel but never ran, it is dropped rather than failed.
+	notRun := func(job Job[T], err error) Result[T, U] {
+		if cause := context.Cause(ctx); cause != nil {
+			err = cause
+		}
+		err = fmt.Errorf("%w: %w", ErrNotRun, err)
+		cfg.metrics.JobDropped()
+		errs.Record(job.ID, err)
+		return Result[T, U]{Job: job, Err: err}
+	}
+
+	// admitKeyed waits for the key of a job first, so a busy key takes neither in-flight slots
+	// nor tokens of the global limiter while it waits, and returns the weight to release once the job is done.
+	admitKeyed := func(job Job[T]) (int64, error) {
+		if err := keys.pass(ctx, job); err != nil {
+			return 0, err
+		}
+		weight, err := bound.acquire(ctx, job)
+		if err != nil {
+			return 0, err
+		}
+		if err := limiter.Wait(ctx); err != nil {
+			bound.release(weight)
+			return 0, err
+		}
+		return weight, nil
+	}
+
 	go func() {
 		wg := sync.WaitGroup{}
+		var last *Result[T, U] // a job that never got a place in the order of results, sent after all others
 		defer func() {
 			// Close the results channel once all workers are done.
 			wg.Wait()
+			if last != nil {
+				results <- *last
+			}
+			close(stopAdapting)
+			errs.Finish()
 			close(results)
 		}()
 
@@ -45,16 +131,62 @@ func NewRateLimited[T any, U any](ctx context.Context, limiter *rate.Limiter, jo
 				if !ok {
 					return // jobs channel closed, exit worker
 				}
-				if err := limiter.Wait(context.Background()); err != nil { // context shutdown is handled elsewhere.
-					results <- Result[T, U]{Job: job, Err: err}
+				queued := time.Now()
+				cfg.metrics.JobQueued()
+
+				var seq uint64
+				if order != nil {
+					if seq, ok = order.Acquire(ctx, nil); !ok {
+						slog.Info("shutting down goroutine", "reason", ctx.Err())
+						result := notRun(job, ctx.Err())
+						last = &result
+						return
+					}
+				}
+				if cfg.breaker.isOpen(time.Now()) {
+					deliver(seq, reject(job, queued, ErrCircuitOpen))
+					continue
+				}
+				var weight int64
+				if keys == nil {
+					var err error
+					if weight, err = bound.acquire(ctx, job); err != nil {
+						slog.Info("shutting down goroutine", "reason", ctx.Err())
+						deliver(seq, notRun(job, err))
+						return
+					}
+					if err := limiter.Wait(ctx); err != nil {
+						slog.Info("shutting down goroutine", "reason", err)
+						bound.release(weight)
+						deliver(seq, notRun(job, err))
+						return
+					}
+				} else if err := keys.enter(ctx); err != nil {
+					slog.Info("shutting down goroutine", "reason", ctx.Err())
+					deliver(seq, notRun(job, err))
 					return
 				}
 				wg.Add(1)
-				go func(job Job[T]) {
+				go func(job Job[T], weight int64) {
 					defer wg.Done()
-					value, err := processFunc(ctx, job.Value)
-					results <- Result[T, U]{Job: job, Value: value, Err: err}
-				}(job)
+					if keys != nil {
+						var err error
+						if weight, err = admitKeyed(job); err != nil {
+							deliver(seq, notRun(job, err))
+							return
+						}
+					}
+					defer bound.release(weight)
+					start := time.Now()
+					cfg.metrics.JobStarted(start.Sub(queued))
+					value, err := panics.Process(ctx, processFunc, job.Value)
+					cfg.metrics.JobFinished(time.Since(start), err)
+					errs.Record(job.ID, err)
+					if adapt != nil {
+						adapt.observe(err, time.Now())
+					}
+					deliver(seq, Result[T, U]{Job: job, Value: value, Err: err})
+				}(job, weight)
 			}
 		}
 	}()
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/time/rate"
 )
 
@@ -107,3 +108,60 @@ func squareNonNegative(_ context.Context, value int) (int, error) {
 	}
 	return value * value, nil
 }
+
+func TestNewRateLimitedReportsJobsThatNeverRan(t *testing.T) {
+	errShutdown := errors.New("shutting down")
+	blockUntilCancelled := func(ctx context.Context, value int) (int, error) {
+		if value == 1 {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return value, nil
+	}
+
+	tests := []struct {
+		name    string
+		limiter *rate.Limiter
+		opts    []Option
+	}{
+		{
+			name:    "Waiting for the limiter",
+			limiter: rate.NewLimiter(rate.Every(time.Hour), 1), // only job 1 gets a token
+		},
+		{
+			name:    "Waiting for a place in the order of results",
+			limiter: rate.NewLimiter(rate.Inf, 1),
+			opts:    []Option{WithOrderedResults(1)}, // job 2 waits for job 1 to finish
+		},
+		{
+			name:    "Waiting for the in-flight bound",
+			limiter: rate.NewLimiter(rate.Inf, 1),
+			opts:    []Option{WithMaxInFlight(1)}, // job 2 waits for job 1 to finish
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil) // ensure resources are cleaned up
+
+			jobs := make(chan Job[int], 3)
+			for i := 1; i <= 3; i++ {
+				jobs <- Job[int]{ID: i, Value: i}
+			}
+			results := NewRateLimited(ctx, tt.limiter, jobs, blockUntilCancelled, tt.opts...)
+
+			time.Sleep(100 * time.Millisecond) // job 1 is running, job 2 is waiting
+			cancel(errShutdown)
+
+			got := map[int]error{}
+			for result := range results {
+				got[result.Job.ID] = result.Err
+			}
+			require.Len(t, got, 2)
+			assert.ErrorIs(t, got[1], context.Canceled)
+			assert.ErrorIs(t, got[2], ErrNotRun)
+			assert.ErrorIs(t, got[2], errShutdown)
+			assert.Len(t, jobs, 1, "job 3 is left in the jobs channel")
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package dynamic
+
+import (
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/errmode"
+)
+
+// ErrorMode decides how an executor handles the errors of its jobs, see WithErrorMode.
+type ErrorMode = errmode.Mode
+
+const (
+	ContinueOnError = errmode.Continue   // keep going after errors, the default
+	FailFast        = errmode.FailFast   // cancel the outstanding jobs on the first error
+	CollectAll      = errmode.CollectAll // finish all jobs and report every error
+)
+
+// JobError attributes an error to the job that returned it.
+type JobError = errmode.JobError
+
+// ErrorReport receives the error summary of an executor once its results channel is closed.
+type ErrorReport = errmode.Report
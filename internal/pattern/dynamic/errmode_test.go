@@ -0,0 +1,93 @@
+package dynamic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestNewRateLimitedErrorMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    ErrorMode
+		wantErr string
+	}{
+		{
+			name: "Continue on error",
+			mode: ContinueOnError,
+		},
+		{
+			name:    "Collect all",
+			mode:    CollectAll,
+			wantErr: "job 2: negative value\njob 4: negative value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs := make(chan Job[int], 4)
+			for i := 1; i <= 4; i++ {
+				value := i
+				if i%2 == 0 {
+					value = -i
+				}
+				jobs <- Job[int]{ID: i, Value: value}
+			}
+			close(jobs)
+
+			var report ErrorReport
+			limiter := rate.NewLimiter(rate.Inf, 1)
+			results := NewRateLimited(context.Background(), limiter, jobs, squareNonNegative, WithErrorMode(tt.mode, &report))
+
+			count := 0
+			for range results {
+				count++
+			}
+			assert.Equal(t, 4, count) // all jobs finish in either mode
+
+			if tt.wantErr == "" {
+				assert.NoError(t, report.Err())
+				return
+			}
+			assert.EqualError(t, report.Err(), tt.wantErr)
+			assert.ErrorIs(t, report.Err(), ErrNegativeValue)
+		})
+	}
+}
+
+func TestNewRateLimitedFailFast(t *testing.T) {
+	started := make(chan struct{})
+	process := func(ctx context.Context, value int) (int, error) {
+		if value < 0 {
+			<-started
+			return 0, ErrNegativeValue
+		}
+		close(started)
+		<-ctx.Done() // runs until the first error cancels it
+		return 0, context.Cause(ctx)
+	}
+
+	jobs := make(chan Job[int], 2)
+	jobs <- Job[int]{ID: 1, Value: 1}
+	jobs <- Job[int]{ID: 2, Value: -2}
+
+	var report ErrorReport
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	results := NewRateLimited(context.Background(), limiter, jobs, process, WithErrorMode(FailFast, &report))
+
+	got := map[int]Result[int, int]{}
+	for result := range results {
+		got[result.Job.ID] = result
+	}
+
+	var jobErr *JobError
+	require.ErrorAs(t, report.Err(), &jobErr)
+	assert.Equal(t, 2, jobErr.JobID)
+
+	// The outstanding job was cancelled with the first error as the cause.
+	require.ErrorAs(t, got[1].Err, &jobErr)
+	assert.Equal(t, 2, jobErr.JobID)
+}
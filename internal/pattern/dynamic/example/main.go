@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"strconv"
 	"time"
@@ -28,8 +29,17 @@ func main() {
 	jobs := make(chan dynamic.Job[int])
 	limiter := rate.NewLimiter(rate.Every(100*time.Millisecond), 10) // Limit to 2 jobs per second with a burst of 10
 
-	// Create a worker pool with 3 workers.
-	results := dynamic.NewRateLimited(ctx, limiter, jobs, FetchPokemonName)
+	// Stop calling the PokeAPI while it keeps failing, and probe it every few seconds until it recovers.
+	breaker := dynamic.NewCircuitBreaker(dynamic.BreakerConfig{
+		FailureThreshold: 3,
+		ProbeInterval:    2 * time.Second,
+		OnChange: func(event dynamic.CircuitEvent) {
+			slog.Warn("Circuit breaker changed state", "from", event.From, "to", event.To, "error", event.Err)
+		},
+	})
+
+	// Create a rate-limited worker pool, protected by the circuit breaker.
+	results := dynamic.NewRateLimited(ctx, limiter, jobs, FetchPokemonName, dynamic.WithCircuitBreaker(breaker))
 
 	// This goroutine sends a new jobs.
 	go func() {
@@ -45,12 +55,17 @@ func main() {
 	}()
 
 	// Process the results.
+	rejected := 0
 	for result := range results {
+		if errors.Is(result.Err, dynamic.ErrCircuitOpen) {
+			rejected++
+			continue
+		}
 		if result.Err != nil {
 			slog.Error("Error processing job", "jobID", result.Job.ID, "error", result.Err)
-			cancel()
 			continue
 		}
 		slog.Info("Result for job", "jobID", result.Job.ID, "result", result.Value)
 	}
+	slog.Info("Jobs rejected while the circuit was open", "count", rejected)
 }
@@ -0,0 +1,57 @@
+package dynamic
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// inFlight bounds the total weight of the jobs processed at the same time.
+// A nil inFlight does not bound anything.
+type inFlight[T any] struct {
+	sem    *semaphore.Weighted
+	limit  int64
+	weight func(Job[T]) int64 // nil if every job weighs 1
+}
+
+// newInFlight returns the in-flight bound set by the options, nil if there is none.
+// It panics if the weight func set with WithWeight does not take jobs of type T.
+func newInFlight[T any](cfg config) *inFlight[T] {
+	if cfg.maxInFlight <= 0 {
+		return nil
+	}
+
+	f := &inFlight[T]{sem: semaphore.NewWeighted(cfg.maxInFlight), limit: cfg.maxInFlight}
+	if cfg.weight != nil {
+		weight, ok := cfg.weight.(func(Job[T]) int64)
+		if !ok {
+			panic(fmt.Sprintf("dynamic: WithWeight func is a %T, want a func(%T) int64", cfg.weight, Job[T]{}))
+		}
+		f.weight = weight
+	}
+	return f
+}
+
+// acquire waits until the job fits within the bound, and returns the weight to release once the job is done.
+func (f *inFlight[T]) acquire(ctx context.Context, job Job[T]) (int64, error) {
+	if f == nil {
+		return 0, nil
+	}
+
+	n := int64(1)
+	if f.weight != nil {
+		n = min(max(f.weight(job), 1), f.limit)
+	}
+	if err := f.sem.Acquire(ctx, n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// release gives back the weight of a job that is done.
+func (f *inFlight[T]) release(n int64) {
+	if f != nil && n > 0 {
+		f.sem.Release(n)
+	}
+}
@@ -0,0 +1,100 @@
+package dynamic
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestNewRateLimitedMaxInFlight(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int64
+		weight   func(Job[int]) int64
+		values   []int
+		wantPeak int64 // highest total weight in flight
+	}{
+		{
+			name:     "Every job weighs 1",
+			limit:    2,
+			values:   []int{1, 1, 1, 1, 1, 1},
+			wantPeak: 2,
+		},
+		{
+			name:     "Heavy jobs run alone",
+			limit:    3,
+			weight:   func(job Job[int]) int64 { return int64(job.Value) },
+			values:   []int{3, 1, 1, 3, 1, 1},
+			wantPeak: 3,
+		},
+		{
+			name:     "Weights are clamped to the bound",
+			limit:    2,
+			weight:   func(job Job[int]) int64 { return int64(job.Value) },
+			values:   []int{5, 0, -1},
+			wantPeak: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				inFlight int64
+				peak     int64
+			)
+			weightOf := func(value int) int64 {
+				if tt.weight == nil {
+					return 1
+				}
+				return min(max(tt.weight(Job[int]{Value: value}), 1), tt.limit)
+			}
+			process := func(_ context.Context, value int) (int, error) {
+				mu.Lock()
+				inFlight += weightOf(value)
+				peak = max(peak, inFlight)
+				mu.Unlock()
+
+				time.Sleep(5 * time.Millisecond)
+
+				mu.Lock()
+				inFlight -= weightOf(value)
+				mu.Unlock()
+				return value, nil
+			}
+
+			jobs := make(chan Job[int], len(tt.values))
+			for i, value := range tt.values {
+				jobs <- Job[int]{ID: i, Value: value}
+			}
+			close(jobs)
+
+			opts := []Option{WithMaxInFlight(tt.limit)}
+			if tt.weight != nil {
+				opts = append(opts, WithWeight(tt.weight))
+			}
+			limiter := rate.NewLimiter(rate.Inf, 1)
+			results := NewRateLimited(context.Background(), limiter, jobs, process, opts...)
+
+			count := 0
+			for range results {
+				count++
+			}
+			assert.Equal(t, len(tt.values), count)
+			assert.Equal(t, tt.wantPeak, peak)
+		})
+	}
+}
+
+func TestNewRateLimitedWeightTypeMismatch(t *testing.T) {
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	weight := func(Job[string]) int64 { return 1 }
+
+	assert.Panics(t, func() {
+		NewRateLimited(context.Background(), limiter, make(chan Job[int]), squareNonNegative, WithMaxInFlight(1), WithWeight(weight))
+	})
+}
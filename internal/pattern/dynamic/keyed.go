@@ -0,0 +1,153 @@
+package dynamic
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/time/rate"
+)
+
+// KeyedLimiter is a registry of rate limiters, one token bucket per key, such as a tenant or a host.
+// It keeps the buckets of at most capacity keys, the least recently used idle key is evicted to make room for a new one.
+// A key is idle once its bucket has refilled, so an evicted key that starts over with a full bucket gets no extra jobs.
+// Busy keys are never evicted, the registry holds more than capacity keys while more keys than that are busy.
+type KeyedLimiter[K comparable] struct {
+	limit    rate.Limit
+	burst    int
+	capacity int
+
+	mu      sync.Mutex
+	lru     *list.List          // keyed buckets, most recently used first
+	buckets map[K]*list.Element // elements of the lru list by key
+}
+
+// keyedBucket is the token bucket of a key.
+type keyedBucket[K comparable] struct {
+	key     K
+	limiter *rate.Limiter
+}
+
+// NewKeyedLimiter creates a registry whose buckets allow limit jobs per second per key, with bursts of up to burst jobs.
+// It keeps at most capacity keys, at least 1.
+func NewKeyedLimiter[K comparable](limit rate.Limit, burst int, capacity int) *KeyedLimiter[K] {
+	return &KeyedLimiter[K]{
+		limit:    limit,
+		burst:    burst,
+		capacity: max(capacity, 1),
+		lru:      list.New(),
+		buckets:  make(map[K]*list.Element),
+	}
+}
+
+// Limiter returns the rate limiter of a key, creating it if needed.
+func (l *KeyedLimiter[K]) Limiter(key K) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elem, ok := l.buckets[key]; ok {
+		l.lru.MoveToFront(elem)
+		return elem.Value.(*keyedBucket[K]).limiter
+	}
+
+	now := time.Now()
+	for elem := l.lru.Back(); elem != nil && l.lru.Len() >= l.capacity; {
+		prev := elem.Prev()
+		if bucket := elem.Value.(*keyedBucket[K]); l.idle(bucket, now) {
+			l.lru.Remove(elem)
+			delete(l.buckets, bucket.key)
+		}
+		elem = prev
+	}
+	bucket := &keyedBucket[K]{key: key, limiter: rate.NewLimiter(l.limit, l.burst)}
+	l.buckets[key] = l.lru.PushFront(bucket)
+	return bucket.limiter
+}
+
+// idle reports whether the bucket of a key has refilled, so a new bucket would allow no more jobs than it does.
+// The bucket of a key with jobs waiting for it is never refilled.
+func (l *KeyedLimiter[K]) idle(bucket *keyedBucket[K], now time.Time) bool {
+	return l.limit == rate.Inf || bucket.limiter.TokensAt(now) >= float64(l.burst)
+}
+
+// Wait blocks until the key's bucket allows a job, or the context is done.
+func (l *KeyedLimiter[K]) Wait(ctx context.Context, key K) error {
+	return l.Limiter(key).Wait(ctx)
+}
+
+// Len returns the number of keys with a bucket.
+func (l *KeyedLimiter[K]) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.lru.Len()
+}
+
+// defaultMaxKeyWaiters is how many jobs can wait for the bucket of their key at the same time, unless WithMaxKeyWaiters is given.
+const defaultMaxKeyWaiters = 1024
+
+// WithKeyedLimiter rate limits every key on its own, on top of the limiter passed to NewRateLimited, which caps all keys together.
+// Every job resolves to a key, and waits for the key's bucket before it takes a WithMaxInFlight slot or waits for the global limiter,
+// so jobs of a busy key never hold up the jobs of other keys, or use up their share of the global rate,
+// as long as fewer jobs than WithMaxKeyWaiters wait for their key at the same time.
+// The jobs must be of the executor's job type, NewRateLimited panics otherwise.
+func WithKeyedLimiter[T any, K comparable](limiter *KeyedLimiter[K], key func(Job[T]) K) Option {
+	return func(c *config) {
+		c.keyWait = func(ctx context.Context, job Job[T]) error {
+			return limiter.Wait(ctx, key(job))
+		}
+	}
+}
+
+// WithMaxKeyWaiters bounds how many jobs can wait for the bucket of their key at the same time, 1024 by default.
+// Every waiting job holds a goroutine, no more jobs are taken from the jobs channel while the bound is reached.
+// It has no effect without WithKeyedLimiter.
+func WithMaxKeyWaiters(n int64) Option {
+	return func(c *config) {
+		c.maxKeyWaiters = max(n, 1)
+	}
+}
+
+// keyGate lets jobs through once the bucket of their key allows them, with a bounded number of jobs waiting.
+// A nil keyGate lets every job through right away.
+type keyGate[T any] struct {
+	wait    func(context.Context, Job[T]) error
+	waiters *semaphore.Weighted
+}
+
+// newKeyGate returns the key gate set by WithKeyedLimiter, nil if there is none.
+// It panics if the key func does not take jobs of type T.
+func newKeyGate[T any](cfg config) *keyGate[T] {
+	if cfg.keyWait == nil {
+		return nil
+	}
+	wait, ok := cfg.keyWait.(func(context.Context, Job[T]) error)
+	if !ok {
+		panic(fmt.Sprintf("dynamic: WithKeyedLimiter key func does not take jobs of type %T", Job[T]{}))
+	}
+	waiters := cfg.maxKeyWaiters
+	if waiters <= 0 {
+		waiters = defaultMaxKeyWaiters
+	}
+	return &keyGate[T]{wait: wait, waiters: semaphore.NewWeighted(waiters)}
+}
+
+// enter waits until one more job can wait for its key. Every successful enter must be followed by a pass.
+func (g *keyGate[T]) enter(ctx context.Context) error {
+	if g == nil {
+		return nil
+	}
+	return g.waiters.Acquire(ctx, 1)
+}
+
+// pass waits for the bucket of the job's key, and makes room for the next waiting job.
+func (g *keyGate[T]) pass(ctx context.Context, job Job[T]) error {
+	if g == nil {
+		return nil
+	}
+	defer g.waiters.Release(1)
+	return g.wait(ctx, job)
+}
@@ -0,0 +1,160 @@
+package dynamic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestKeyedLimiter(t *testing.T) {
+	limiter := NewKeyedLimiter[string](rate.Every(time.Second), 1, 2)
+
+	a := limiter.Limiter("a")
+	b := limiter.Limiter("b")
+	assert.Same(t, a, limiter.Limiter("a")) // a is now the most recently used key
+	assert.NotSame(t, a, b)
+
+	limiter.Limiter("c") // evicts b, the least recently used key
+	assert.Equal(t, 2, limiter.Len())
+	assert.Same(t, a, limiter.Limiter("a"))
+	assert.NotSame(t, b, limiter.Limiter("b")) // b starts over with a new bucket
+	assert.Equal(t, 2, limiter.Len())
+}
+
+func TestNewRateLimitedKeyedLimiter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	type request struct {
+		tenant string
+	}
+	process := func(_ context.Context, r request) (string, error) {
+		return r.tenant, nil
+	}
+
+	// The noisy tenant queues five jobs before the quiet tenant queues one.
+	jobs := make(chan Job[request], 6)
+	for i := 0; i < 5; i++ {
+		jobs <- Job[request]{ID: i, Value: request{tenant: "noisy"}}
+	}
+	jobs <- Job[request]{ID: 5, Value: request{tenant: "quiet"}}
+	close(jobs)
+
+	// Every tenant gets one job per hour, the global limiter allows all jobs.
+	perTenant := NewKeyedLimiter[string](rate.Every(time.Hour), 1, 10)
+	global := rate.NewLimiter(rate.Inf, 1)
+	byTenant := func(job Job[request]) string { return job.Value.tenant }
+	results := NewRateLimited(ctx, global, jobs, process, WithKeyedLimiter(perTenant, byTenant))
+
+	// The quiet tenant is not held up by the noisy tenant's backlog.
+	got := map[string]int{}
+	for i := 0; i < 2; i++ {
+		result := <-results
+		assert.NoError(t, result.Err)
+		got[result.Value]++
+	}
+	assert.Equal(t, map[string]int{"noisy": 1, "quiet": 1}, got)
+
+	// The rest of the noisy tenant's jobs wait for its bucket until the context is cancelled.
+	cancel()
+	failed := 0
+	for result := range results {
+		assert.Error(t, result.Err)
+		failed++
+	}
+	assert.Equal(t, 4, failed)
+}
+
+func TestNewRateLimitedKeyedLimiterInFlight(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	process := func(_ context.Context, tenant string) (string, error) {
+		return tenant, nil
+	}
+
+	// The noisy tenant's jobs wait for its bucket, they must not hold the only in-flight slot meanwhile.
+	jobs := make(chan Job[string], 4)
+	for i := 0; i < 3; i++ {
+		jobs <- Job[string]{ID: i, Value: "noisy"}
+	}
+	jobs <- Job[string]{ID: 3, Value: "quiet"}
+	close(jobs)
+
+	perTenant := NewKeyedLimiter[string](rate.Every(time.Hour), 1, 10)
+	global := rate.NewLimiter(rate.Inf, 1)
+	byTenant := func(job Job[string]) string { return job.Value }
+	results := NewRateLimited(ctx, global, jobs, process, WithKeyedLimiter(perTenant, byTenant), WithMaxInFlight(1))
+
+	got := map[string]int{}
+	for i := 0; i < 2; i++ {
+		select {
+		case result := <-results:
+			assert.NoError(t, result.Err)
+			got[result.Value]++
+		case <-time.After(time.Second):
+			t.Fatal("the quiet tenant is held up by the noisy tenant")
+		}
+	}
+	assert.Equal(t, map[string]int{"noisy": 1, "quiet": 1}, got)
+}
+
+func TestNewRateLimitedMaxKeyWaiters(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	process := func(_ context.Context, tenant string) (string, error) {
+		return tenant, nil
+	}
+
+	perTenant := NewKeyedLimiter[string](rate.Every(time.Hour), 1, 10)
+	global := rate.NewLimiter(rate.Inf, 1)
+	byTenant := func(job Job[string]) string { return job.Value }
+	jobs := make(chan Job[string])
+	results := NewRateLimited(ctx, global, jobs, process, WithKeyedLimiter(perTenant, byTenant), WithMaxKeyWaiters(2))
+
+	// The first job uses the bucket, the next two wait for it, and the fourth waits for one of them to finish.
+	for i := 0; i < 4; i++ {
+		jobs <- Job[string]{ID: i, Value: "noisy"}
+	}
+	assert.Equal(t, "noisy", (<-results).Value)
+	select {
+	case jobs <- Job[string]{ID: 4, Value: "noisy"}:
+		t.Fatal("a job was taken while the key waiters bound was reached")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel()
+	failed := 0
+	for result := range results {
+		assert.ErrorIs(t, result.Err, ErrNotRun)
+		failed++
+	}
+	assert.Equal(t, 3, failed)
+}
+
+func TestKeyedLimiterBusyKeys(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	// Three keys are busy, one more than the capacity, none of them may start over with a full bucket.
+	limiter := NewKeyedLimiter[string](rate.Every(100*time.Millisecond), 1, 2)
+	keys := []string{"a", "b", "c"}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		for _, key := range keys {
+			assert.NoError(t, limiter.Wait(ctx, key))
+		}
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond, "every key is limited to one job per 100ms")
+	assert.Equal(t, 3, limiter.Len())
+
+	// Once the buckets have refilled, the least recently used keys are evicted again.
+	time.Sleep(100 * time.Millisecond)
+	limiter.Limiter("d")
+	assert.Equal(t, 2, limiter.Len())
+}
@@ -0,0 +1,55 @@
+package dynamic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/metrics"
+)
+
+func TestNewRateLimitedMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	jobs := []Job[int]{{ID: 1, Value: 2}, {ID: 2, Value: -1}, {ID: 3, Value: 3}}
+	jobsChan := make(chan Job[int], len(jobs))
+	for _, job := range jobs {
+		jobsChan <- job
+	}
+	close(jobsChan)
+
+	m := metrics.NewInMemory("dynamic")
+	limiter := rate.NewLimiter(rate.Every(time.Millisecond), 1)
+	for range NewRateLimited(ctx, limiter, jobsChan, squareNonNegative, WithMetrics(m)) {
+	}
+
+	assert.Equal(t, metrics.Snapshot{Completed: 2, Failed: 1}, m.Snapshot())
+}
+
+func TestNewRateLimitedMetricsNotRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	jobsChan := make(chan Job[int], 3)
+	for i := 1; i <= 3; i++ {
+		jobsChan <- Job[int]{ID: i, Value: i}
+	}
+	close(jobsChan)
+
+	// Only the first job gets a token, the second never runs, the third is left in the jobs channel.
+	m := metrics.NewInMemory("dynamic")
+	limiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+	results := NewRateLimited(ctx, limiter, jobsChan, squareNonNegative, WithMetrics(m))
+
+	<-results
+	cancel()
+	for result := range results {
+		assert.ErrorIs(t, result.Err, ErrNotRun)
+	}
+
+	assert.Equal(t, metrics.Snapshot{Completed: 1, Dropped: 1}, m.Snapshot())
+}
@@ -0,0 +1,87 @@
+package dynamic
+
+import (
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/metrics"
+)
+
+// config holds the optional settings of an executor.
+type config struct {
+	orderWindow   int
+	metrics       metrics.Metrics
+	errorMode     ErrorMode
+	errorReport   *ErrorReport
+	maxInFlight   int64
+	weight        any // func(Job[T]) int64 for the executor's job type T, nil if every job weighs 1
+	adaptive      *AdaptiveConfig
+	keyWait       any // func(context.Context, Job[T]) error for the executor's job type T, nil if jobs are not limited per key
+	maxKeyWaiters int64
+	breaker       *CircuitBreaker
+}
+
+// Option configures optional behaviour of an executor.
+type Option func(*config)
+
+// WithOrderedResults releases results in the order jobs were submitted.
+// At most window jobs can be in flight or waiting for an earlier job to finish,
+// once the window is full no more jobs are started until the oldest result is released.
+func WithOrderedResults(window int) Option {
+	return func(c *config) {
+		c.orderWindow = max(window, 1)
+	}
+}
+
+// WithMetrics reports the lifecycle of every job to the given metrics.
+// The queue wait of a job includes the time spent waiting for the rate limiter.
+// Jobs that never run, see ErrNotRun, are reported as dropped.
+func WithMetrics(m metrics.Metrics) Option {
+	return func(c *config) {
+		c.metrics = m
+	}
+}
+
+// WithErrorMode sets how the errors of jobs are handled, and the report that receives the error summary.
+// FailFast cancels the context of the outstanding jobs on the first error, with its JobError as the cause,
+// CollectAll lets all jobs finish and reports the JobError of every failed job, joined.
+// The report is complete once the results channel is closed, it may be nil if only the behaviour is needed.
+func WithErrorMode(mode ErrorMode, report *ErrorReport) Option {
+	return func(c *config) {
+		c.errorMode = mode
+		c.errorReport = report
+	}
+}
+
+// WithMaxInFlight bounds the total weight of the jobs processed at the same time, on top of the rate limit.
+// Every job weighs 1 unless WithWeight is also given. No more jobs are started while the bound is reached,
+// the time spent waiting counts as queue wait in the metrics.
+func WithMaxInFlight(n int64) Option {
+	return func(c *config) {
+		c.maxInFlight = max(n, 1)
+	}
+}
+
+// WithWeight sets how much of the WithMaxInFlight bound a job takes, so heavy jobs can cost more.
+// Weights are clamped between 1 and the bound. The jobs must be of the executor's job type,
+// NewRateLimited panics otherwise.
+func WithWeight[T any](weight func(Job[T]) int64) Option {
+	return func(c *config) {
+		c.weight = weight
+	}
+}
+
+// WithAdaptiveRate adjusts the limiter's rate to the errors of the jobs, additive increase, multiplicative decrease.
+// The rate is multiplied by cfg.Decrease as soon as a job fails with an error that cfg.IsOverload reports as overload,
+// such as a timeout or an HTTP 429, and increased by cfg.Increase after every interval in which jobs succeeded without overload.
+// The rate always stays between cfg.Floor and cfg.Ceiling.
+func WithAdaptiveRate(cfg AdaptiveConfig) Option {
+	return func(c *config) {
+		c.adaptive = &cfg
+	}
+}
+
+func newConfig(opts []Option) config {
+	c := config{metrics: metrics.Nop{}}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
@@ -0,0 +1,34 @@
+package dynamic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestNewRateLimitedOrderedResults(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	jobsChan := make(chan Job[int], 10)
+	for i := 0; i < 10; i++ {
+		jobsChan <- Job[int]{ID: i, Value: i}
+	}
+	close(jobsChan)
+
+	// Lower values take longer, so results complete in reverse order.
+	limiter := rate.NewLimiter(rate.Every(time.Millisecond), 10)
+	results := NewRateLimited(ctx, limiter, jobsChan, func(_ context.Context, value int) (int, error) {
+		time.Sleep(time.Duration(10-value) * time.Millisecond)
+		return value, nil
+	}, WithOrderedResults(4))
+
+	var gotIDs []int
+	for result := range results {
+		gotIDs = append(gotIDs, result.Job.ID)
+	}
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, gotIDs)
+}
@@ -0,0 +1,9 @@
+package dynamic
+
+import (
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/panics"
+)
+
+// PanicError is reported in Result.Err when a ProcessFunc panics.
+// It is the same type in every executor package, so errors.As matches it whichever executor ran the job.
+type PanicError = panics.Error
@@ -0,0 +1,43 @@
+package dynamic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestNewRateLimitedPanic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	jobs := []Job[int]{{ID: 1, Value: -1}, {ID: 2, Value: 2}}
+	jobsChan := make(chan Job[int], len(jobs))
+	for _, job := range jobs {
+		jobsChan <- job
+	}
+	close(jobsChan)
+
+	limiter := rate.NewLimiter(rate.Every(time.Millisecond), 10)
+	results := NewRateLimited(ctx, limiter, jobsChan, func(ctx context.Context, value int) (int, error) {
+		if value < 0 {
+			panic(ErrNegativeValue)
+		}
+		return squareNonNegative(ctx, value)
+	})
+
+	for result := range results {
+		if result.Job.ID == 2 {
+			assert.Equal(t, Result[int, int]{Job: jobs[1], Value: 4}, result)
+			continue
+		}
+
+		var panicErr *PanicError
+		require.ErrorAs(t, result.Err, &panicErr)
+		assert.ErrorIs(t, result.Err, ErrNegativeValue)
+		assert.NotEmpty(t, panicErr.Stack)
+	}
+}
@@ -0,0 +1,76 @@
+package fanoutin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFanOutErrorMode(t *testing.T) {
+	jobs := []Job[int]{{ID: 1, Value: 1}, {ID: 2, Value: -2}, {ID: 3, Value: 3}, {ID: 4, Value: -4}}
+
+	tests := []struct {
+		name    string
+		mode    ErrorMode
+		wantErr string
+	}{
+		{
+			name: "Continue on error",
+			mode: ContinueOnError,
+		},
+		{
+			name:    "Collect all",
+			mode:    CollectAll,
+			wantErr: "job 2: negative value\njob 4: negative value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var report ErrorReport
+			results := FanOut(context.Background(), jobs, squareNonNegative, WithErrorMode(tt.mode, &report))
+
+			count := 0
+			for range results {
+				count++
+			}
+			assert.Equal(t, len(jobs), count) // all jobs finish in either mode
+
+			if tt.wantErr == "" {
+				assert.NoError(t, report.Err())
+				return
+			}
+			assert.EqualError(t, report.Err(), tt.wantErr)
+			assert.ErrorIs(t, report.Err(), ErrNegativeValue)
+		})
+	}
+}
+
+func TestFanOutFailFast(t *testing.T) {
+	process := func(ctx context.Context, value int) (int, error) {
+		if value < 0 {
+			return 0, ErrNegativeValue
+		}
+		<-ctx.Done() // runs until the first error cancels it
+		return 0, context.Cause(ctx)
+	}
+
+	var report ErrorReport
+	results := FanOut(context.Background(), []Job[int]{{ID: 1, Value: 1}, {ID: 2, Value: -2}}, process, WithErrorMode(FailFast, &report))
+
+	got := map[int]Result[int, int]{}
+	for result := range results {
+		got[result.Job.ID] = result
+	}
+
+	var jobErr *JobError
+	require.ErrorAs(t, report.Err(), &jobErr)
+	assert.Equal(t, 2, jobErr.JobID)
+	assert.ErrorIs(t, report.Err(), ErrNegativeValue)
+
+	// The outstanding job was cancelled with the first error as the cause.
+	require.ErrorAs(t, got[1].Err, &jobErr)
+	assert.Equal(t, 2, jobErr.JobID)
+}
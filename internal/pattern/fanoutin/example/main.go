@@ -28,16 +28,19 @@ func main() {
 		jobs = append(jobs, fanoutin.Job[int]{ID: i, Value: i})
 	}
 
-	// Fan out
-	results := fanoutin.FanOut(ctx, jobs, squareNonNegative)
+	// Fan out, cancelling the outstanding jobs on the first error.
+	var report fanoutin.ErrorReport
+	results := fanoutin.FanOut(ctx, jobs, squareNonNegative, fanoutin.WithErrorMode(fanoutin.FailFast, &report))
 
 	// Fan in
 	for result := range results {
 		if result.Err != nil {
 			slog.Error("Error processing job", "jobID", result.Job.ID, "error", result.Err)
-			cancel()
 			continue
 		}
 		slog.Info("Result for job", "jobID", result.Job.ID, "result", result.Value)
 	}
+	if err := report.Err(); err != nil {
+		slog.Error("Stopped after the first error", "error", err)
+	}
 }
@@ -4,6 +4,10 @@ import (
 	"context"
 	"log/slog"
 	"sync"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/errmode"
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/panics"
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/reorder"
 )
 
 // Job holds information about each job.
@@ -23,15 +27,25 @@ type Result[T any, U any] struct {
 type ProcessFunc[T any, U any] func(context.Context, T) (U, error)
 
 // FanOut creates a pool of workers.
-func FanOut[T any, U any](ctx context.Context, jobs []Job[T], processFunc ProcessFunc[T, U]) chan Result[T, U] {
+func FanOut[T any, U any](ctx context.Context, jobs []Job[T], processFunc ProcessFunc[T, U], opts ...Option) chan Result[T, U] {
 	results := make(chan Result[T, U], len(jobs))
 	var wg sync.WaitGroup
 
+	cfg := newConfig(opts)
+	ctx, errs := errmode.New(ctx, cfg.errorMode, cfg.errorReport)
+	var order *reorder.Buffer[Result[T, U]]
+	if cfg.orderWindow > 0 {
+		order = reorder.New(cfg.orderWindow, func(result Result[T, U]) {
+			results <- result
+		})
+	}
+
 	// Launch a new worker for each job.
 	go func() {
 		defer func() {
 			// Close the results channel once all workers are done.
 			wg.Wait()
+			errs.Finish()
 			close(results)
 		}()
 
@@ -41,12 +55,27 @@ func FanOut[T any, U any](ctx context.Context, jobs []Job[T], processFunc Proces
 				slog.Info("shutting down goroutine", "reason", ctx.Err(), "total jobs", len(jobs), "finished jobs", i)
 				return
 			default:
+				var seq uint64
+				if order != nil {
+					var ok bool
+					if seq, ok = order.Acquire(ctx, nil); !ok {
+						slog.Info("shutting down goroutine", "reason", ctx.Err(), "total jobs", len(jobs), "finished jobs", i)
+						return
+					}
+				}
+
 				wg.Add(1) // Increment the counter whenever a new job is received.
 				go func(job Job[T]) {
 					defer wg.Done() // Decrement the counter when the goroutine completes.
 
-					value, err := processFunc(ctx, job.Value)
-					results <- Result[T, U]{Job: job, Value: value, Err: err}
+					value, err := panics.Process(ctx, processFunc, job.Value)
+					errs.Record(job.ID, err)
+					result := Result[T, U]{Job: job, Value: value, Err: err}
+					if order != nil {
+						order.Release(seq, result) // Hold the result back until all earlier results are sent.
+						return
+					}
+					results <- result
 				}(job)
 			}
 		}
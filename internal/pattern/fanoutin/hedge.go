@@ -0,0 +1,137 @@
+package fanoutin
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/panics"
+)
+
+// HedgeStats counts the outcomes of hedged requests, see Hedge.
+// The zero value is ready to use, and it is safe for concurrent use.
+type HedgeStats struct {
+	mu     sync.Mutex
+	counts HedgeCounts
+}
+
+// HedgeCounts is a snapshot of HedgeStats.
+type HedgeCounts struct {
+	Requests int   // hedged requests that finished
+	Hedged   int   // requests for which the hedge delay fired at least once, starting a backup
+	Failed   int   // requests for which every replica failed, or whose context was done first
+	Wins     []int // successful requests per replica, by index, the first replica is the primary
+}
+
+// Counts returns a snapshot of the counts.
+func (s *HedgeStats) Counts() HedgeCounts {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := s.counts
+	counts.Wins = slices.Clone(s.counts.Wins)
+	return counts
+}
+
+// record counts a finished request, winner is -1 if it failed. It does nothing for nil stats.
+func (s *HedgeStats) record(replicas int, hedged bool, winner int) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.counts.Wins) < replicas {
+		s.counts.Wins = append(s.counts.Wins, make([]int, replicas-len(s.counts.Wins))...)
+	}
+	s.counts.Requests++
+	if hedged {
+		s.counts.Hedged++
+	}
+	if winner < 0 {
+		s.counts.Failed++
+		return
+	}
+	s.counts.Wins[winner]++
+}
+
+// Hedge returns a ProcessFunc that sends the same value to several replicas and returns the first successful result.
+// The first replica starts right away, every following replica starts once the previous one has not succeeded
+// within delay, or right after it failed. The losers are cancelled through their context once a replica succeeds,
+// without waiting for them to return. If every replica fails, their errors are returned, joined in replica order.
+// A delay of zero or less starts all replicas at once. stats may be nil. Hedge panics if there are no replicas.
+func Hedge[T any, U any](delay time.Duration, stats *HedgeStats, replicas ...ProcessFunc[T, U]) ProcessFunc[T, U] {
+	if len(replicas) == 0 {
+		panic("fanoutin: Hedge needs at least one replica")
+	}
+
+	type outcome struct {
+		replica int
+		value   U
+		err     error
+	}
+
+	return func(ctx context.Context, value T) (U, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel() // Cancel the losers.
+
+		outcomes := make(chan outcome, len(replicas)) // Buffered, so the losers never block.
+		started, running := 0, 0
+		start := func() {
+			replica := started
+			started++
+			running++
+			go func() {
+				result, err := panics.Process(ctx, replicas[replica], value)
+				outcomes <- outcome{replica: replica, value: result, err: err}
+			}()
+		}
+
+		start()
+		for delay <= 0 && started < len(replicas) {
+			start()
+		}
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		hedged := false
+		errs := make([]error, len(replicas))
+		for {
+			var hedge <-chan time.Time
+			if started < len(replicas) {
+				hedge = timer.C
+			}
+
+			select {
+			case <-ctx.Done():
+				stats.record(len(replicas), hedged, -1)
+				var zero U
+				return zero, ctx.Err()
+			case <-hedge:
+				hedged = true
+				start()
+				timer.Reset(delay)
+			case o := <-outcomes:
+				running--
+				if o.err == nil {
+					stats.record(len(replicas), hedged, o.replica)
+					return o.value, nil
+				}
+				errs[o.replica] = o.err
+				if started < len(replicas) {
+					start() // Don't wait for the delay once a replica failed.
+					timer.Reset(delay)
+					continue
+				}
+				if running == 0 {
+					stats.record(len(replicas), hedged, -1)
+					var zero U
+					return zero, errors.Join(errs...)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package fanoutin
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errReplica = errors.New("replica failed")
+
+// replica returns a ProcessFunc that answers after a delay, or fails, and reports whether it was cancelled.
+func replica(name string, delay time.Duration, fail bool, cancelled *atomic.Bool) ProcessFunc[int, string] {
+	return func(ctx context.Context, _ int) (string, error) {
+		select {
+		case <-ctx.Done():
+			if cancelled != nil {
+				cancelled.Store(true)
+			}
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+		if fail {
+			return "", errReplica
+		}
+		return name, nil
+	}
+}
+
+func TestHedge(t *testing.T) {
+	tests := []struct {
+		name      string
+		replicas  []ProcessFunc[int, string]
+		want      string
+		wantErr   error
+		wantCount HedgeCounts
+	}{
+		{
+			name:      "Fast primary",
+			replicas:  []ProcessFunc[int, string]{replica("primary", 0, false, nil), replica("backup", 0, false, nil)},
+			want:      "primary",
+			wantCount: HedgeCounts{Requests: 1, Wins: []int{1, 0}},
+		},
+		{
+			name:      "Slow primary",
+			replicas:  []ProcessFunc[int, string]{replica("primary", time.Second, false, nil), replica("backup", 0, false, nil)},
+			want:      "backup",
+			wantCount: HedgeCounts{Requests: 1, Hedged: 1, Wins: []int{0, 1}},
+		},
+		{
+			name:      "Failed primary",
+			replicas:  []ProcessFunc[int, string]{replica("primary", 0, true, nil), replica("backup", 0, false, nil)},
+			want:      "backup",
+			wantCount: HedgeCounts{Requests: 1, Wins: []int{0, 1}},
+		},
+		{
+			name:      "All failed",
+			replicas:  []ProcessFunc[int, string]{replica("primary", 0, true, nil), replica("backup", 0, true, nil)},
+			wantErr:   errReplica,
+			wantCount: HedgeCounts{Requests: 1, Failed: 1, Wins: []int{0, 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stats HedgeStats
+			process := Hedge(50*time.Millisecond, &stats, tt.replicas...)
+
+			got, err := process(context.Background(), 1)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantCount, stats.Counts())
+		})
+	}
+}
+
+func TestHedgeCancelsLosers(t *testing.T) {
+	var primaryCancelled, backupCancelled atomic.Bool
+	process := Hedge(20*time.Millisecond, nil,
+		replica("primary", time.Second, false, &primaryCancelled),
+		replica("backup", 10*time.Millisecond, false, &backupCancelled),
+		replica("unused", 0, false, nil),
+	)
+
+	start := time.Now()
+	got, err := process(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "backup", got)
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "the slow primary is not waited for")
+	assert.Eventually(t, primaryCancelled.Load, time.Second, time.Millisecond)
+	assert.False(t, backupCancelled.Load())
+}
+
+func TestHedgeCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel() // ensure resources are cleaned up
+
+	var stats HedgeStats
+	process := Hedge(time.Hour, &stats, replica("primary", time.Second, false, nil), replica("backup", 0, false, nil))
+
+	_, err := process(ctx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, HedgeCounts{Requests: 1, Failed: 1, Wins: []int{0, 0}}, stats.Counts())
+}
+
+func TestHedgeWithFanOut(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	var stats HedgeStats
+	process := Hedge(0, &stats, replica("primary", time.Second, false, nil), replica("backup", 0, false, nil))
+
+	for result := range FanOutSeq(ctx, count(10), 5, process) {
+		require.NoError(t, result.Err)
+		assert.Equal(t, "backup", result.Value)
+	}
+	assert.Equal(t, HedgeCounts{Requests: 10, Wins: []int{0, 10}}, stats.Counts())
+}
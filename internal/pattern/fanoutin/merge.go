@@ -0,0 +1,156 @@
+package fanoutin
+
+import (
+	"context"
+	"reflect"
+)
+
+// Tagged is an item merged by MergeTagged, along with the index of the input it came from.
+type Tagged[T any] struct {
+	Source int
+	Value  T
+}
+
+// mergeConfig holds the optional settings of a merge.
+type mergeConfig struct {
+	weights []int
+}
+
+// MergeOption configures optional behaviour of Merge and MergeTagged.
+type MergeOption func(*mergeConfig)
+
+// WithWeights gives every input, by index, a share of the output when several inputs have items ready.
+// An input with weight 3 gets up to three items through for every item of an input with weight 1.
+// Inputs without a positive weight weigh 1, which is also the default, so inputs take turns round-robin.
+func WithWeights(weights ...int) MergeOption {
+	return func(c *mergeConfig) {
+		c.weights = weights
+	}
+}
+
+// Merge fans in the items of all inputs into one channel, in turns, so a busy input can't starve the others.
+// The output is closed once all inputs are closed and drained, or the context is done.
+// Items left in the inputs once the context is done are not read, nil inputs count as closed.
+func Merge[T any](ctx context.Context, inputs []<-chan T, opts ...MergeOption) <-chan T {
+	return merge(ctx, inputs, opts, func(_ int, value T) T {
+		return value
+	})
+}
+
+// MergeTagged is Merge, with every item tagged with the index of the input it came from.
+func MergeTagged[T any](ctx context.Context, inputs []<-chan T, opts ...MergeOption) <-chan Tagged[T] {
+	return merge(ctx, inputs, opts, func(source int, value T) Tagged[T] {
+		return Tagged[T]{Source: source, Value: value}
+	})
+}
+
+// mergeInput is an input that is not drained yet.
+type mergeInput[T any] struct {
+	source int
+	ch     <-chan T
+	weight int
+}
+
+// merge reads the inputs in rounds, taking up to the weight of every input from it while it has items ready,
+// and only blocks on all inputs at once if none of them had an item ready for a whole round.
+func merge[T any, V any](ctx context.Context, inputs []<-chan T, opts []MergeOption, wrap func(int, T) V) <-chan V {
+	var cfg mergeConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var active []mergeInput[T]
+	for i, ch := range inputs {
+		if ch == nil {
+			continue
+		}
+		weight := 1
+		if i < len(cfg.weights) && cfg.weights[i] > 0 {
+			weight = cfg.weights[i]
+		}
+		active = append(active, mergeInput[T]{source: i, ch: ch, weight: weight})
+	}
+
+	out := make(chan V)
+	// send sends an item, it returns false if the context is done first.
+	send := func(source int, value T) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case out <- wrap(source, value):
+			return true
+		}
+	}
+
+	go func() {
+		defer close(out)
+
+		for len(active) > 0 {
+			progressed := false
+			for i := 0; i < len(active); {
+				in := active[i]
+				closed := false
+			turn:
+				for n := 0; n < in.weight; n++ {
+					select {
+					case <-ctx.Done():
+						return
+					case value, ok := <-in.ch:
+						if !ok {
+							closed = true
+							break turn
+						}
+						if !send(in.source, value) {
+							return
+						}
+						progressed = true
+					default:
+						break turn // nothing ready, it's the next input's turn
+					}
+				}
+				if closed {
+					active = append(active[:i], active[i+1:]...)
+					progressed = true
+					continue
+				}
+				i++
+			}
+			if progressed {
+				continue
+			}
+
+			// No input had an item ready, wait for any of them.
+			i, value, ok := receiveAny(ctx, active)
+			switch {
+			case i < 0:
+				return // context done
+			case !ok:
+				active = append(active[:i], active[i+1:]...)
+			case !send(active[i].source, value):
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// receiveAny blocks until any of the inputs has an item or is closed, and returns its index in the inputs.
+// The index is -1 if the context is done first.
+func receiveAny[T any](ctx context.Context, inputs []mergeInput[T]) (int, T, bool) {
+	cases := make([]reflect.SelectCase, 0, len(inputs)+1)
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+	for _, in := range inputs {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(in.ch)})
+	}
+
+	chosen, recv, ok := reflect.Select(cases)
+	var value T
+	if chosen == 0 {
+		return -1, value, false
+	}
+	if ok {
+		value, _ = recv.Interface().(T)
+	}
+	return chosen - 1, value, ok
+}
@@ -0,0 +1,110 @@
+package fanoutin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ready returns a closed input with all items ready to be read.
+func ready[T any](items ...T) <-chan T {
+	ch := make(chan T, len(items))
+	for _, item := range items {
+		ch <- item
+	}
+	close(ch)
+	return ch
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []<-chan string
+		opts   []MergeOption
+		want   []string
+	}{
+		{
+			name:   "Round-robin",
+			inputs: []<-chan string{ready("a1", "a2", "a3", "a4"), ready("b1", "b2"), ready("c1")},
+			want:   []string{"a1", "b1", "c1", "a2", "b2", "a3", "a4"},
+		},
+		{
+			name:   "Weighted",
+			inputs: []<-chan string{ready("a1", "a2", "a3", "a4", "a5", "a6"), ready("b1", "b2", "b3")},
+			opts:   []MergeOption{WithWeights(2, 1)},
+			want:   []string{"a1", "a2", "b1", "a3", "a4", "b2", "a5", "a6", "b3"},
+		},
+		{
+			name:   "Nil and empty inputs",
+			inputs: []<-chan string{nil, ready[string](), ready("c1")},
+			want:   []string{"c1"},
+		},
+		{
+			name: "No inputs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			var got []string
+			for item := range Merge(ctx, tt.inputs, tt.opts...) {
+				got = append(got, item)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMergeTagged(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	// The inputs are closed at different times, the output is closed after the last one.
+	slow := make(chan int)
+	go func() {
+		defer close(slow)
+		time.Sleep(50 * time.Millisecond)
+		slow <- 30
+	}()
+	inputs := []<-chan int{ready(10, 11), ready[int](), slow}
+
+	var got []Tagged[int]
+	for item := range MergeTagged(ctx, inputs) {
+		got = append(got, item)
+	}
+	assert.Equal(t, []Tagged[int]{{Source: 0, Value: 10}, {Source: 0, Value: 11}, {Source: 2, Value: 30}}, got)
+}
+
+func TestMergeCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Neither input is ever closed, and nobody reads the output after the first item.
+	busy := make(chan int, 1)
+	busy <- 1
+	out := Merge(ctx, []<-chan int{busy, make(chan int)})
+	assert.Equal(t, 1, <-out)
+	busy <- 2
+
+	cancel()
+	select {
+	case <-drained(out):
+	case <-time.After(time.Second):
+		t.Fatal("output was not closed after the context was cancelled")
+	}
+}
+
+// drained returns a channel that is closed once ch is closed, discarding its items.
+func drained[T any](ch <-chan T) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range ch {
+		}
+	}()
+	return done
+}
@@ -0,0 +1,39 @@
+package fanoutin
+
+// config holds the optional settings of an executor.
+type config struct {
+	orderWindow int
+	errorMode   ErrorMode
+	errorReport *ErrorReport
+}
+
+// Option configures optional behaviour of an executor.
+type Option func(*config)
+
+// WithOrderedResults releases results in the order jobs were submitted.
+// At most window jobs can be in flight or waiting for an earlier job to finish,
+// once the window is full no more jobs are started until the oldest result is released.
+func WithOrderedResults(window int) Option {
+	return func(c *config) {
+		c.orderWindow = max(window, 1)
+	}
+}
+
+// WithErrorMode sets how the errors of jobs are handled, and the report that receives the error summary.
+// FailFast cancels the context of the outstanding jobs on the first error, with its JobError as the cause,
+// CollectAll lets all jobs finish and reports the JobError of every failed job, joined.
+// The report is complete once the results channel is closed, it may be nil if only the behaviour is needed.
+func WithErrorMode(mode ErrorMode, report *ErrorReport) Option {
+	return func(c *config) {
+		c.errorMode = mode
+		c.errorReport = report
+	}
+}
+
+func newConfig(opts []Option) config {
+	var c config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
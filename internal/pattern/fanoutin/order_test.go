@@ -0,0 +1,31 @@
+package fanoutin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFanOutOrderedResults(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	var jobs []Job[int]
+	for i := 0; i < 10; i++ {
+		jobs = append(jobs, Job[int]{ID: i, Value: i})
+	}
+
+	// Lower values take longer, so results complete in reverse order.
+	results := FanOut(ctx, jobs, func(_ context.Context, value int) (int, error) {
+		time.Sleep(time.Duration(10-value) * time.Millisecond)
+		return value, nil
+	}, WithOrderedResults(4))
+
+	var gotIDs []int
+	for result := range results {
+		gotIDs = append(gotIDs, result.Job.ID)
+	}
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, gotIDs)
+}
@@ -0,0 +1,34 @@
+package fanoutin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFanOutPanic(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	jobs := []Job[int]{{ID: 1, Value: -1}, {ID: 2, Value: 2}}
+	results := FanOut(ctx, jobs, func(ctx context.Context, value int) (int, error) {
+		if value < 0 {
+			panic(ErrNegativeValue)
+		}
+		return squareNonNegative(ctx, value)
+	})
+
+	for result := range results {
+		if result.Job.ID == 2 {
+			assert.Equal(t, Result[int, int]{Job: jobs[1], Value: 4}, result)
+			continue
+		}
+
+		var panicErr *PanicError
+		require.ErrorAs(t, result.Err, &panicErr)
+		assert.ErrorIs(t, result.Err, ErrNegativeValue)
+		assert.NotEmpty(t, panicErr.Stack)
+	}
+}
@@ -0,0 +1,136 @@
+package fanoutin
+
+import (
+	"context"
+	"iter"
+	"log/slog"
+	"sync"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/errmode"
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/panics"
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/reorder"
+)
+
+// FanOutSeq processes the jobs of a sequence with at most parallelism jobs in flight, and returns results as they finish.
+// Jobs are pulled from the sequence only once a slot is free, so the sequence can be far larger than memory.
+// A slot is freed once the result of its job is sent, so a slow reader of the results also slows down the fan-out.
+// Once the context is done, no more jobs are pulled, and results that can't be sent yet are dropped.
+// The results channel is closed once the sequence ends or the context is done, and all jobs in flight have finished.
+func FanOutSeq[T any, U any](ctx context.Context, jobs iter.Seq[Job[T]], parallelism int, processFunc ProcessFunc[T, U], opts ...Option) <-chan Result[T, U] {
+	return fanOutStream(ctx, parallelism, processFunc, opts, func(context.Context) iter.Seq[Job[T]] {
+		return jobs
+	})
+}
+
+// FanOutChan is FanOutSeq for jobs read from a channel, it stops reading once the jobs channel is closed or the context is done.
+func FanOutChan[T any, U any](ctx context.Context, jobs <-chan Job[T], parallelism int, processFunc ProcessFunc[T, U], opts ...Option) <-chan Result[T, U] {
+	return fanOutStream(ctx, parallelism, processFunc, opts, func(ctx context.Context) iter.Seq[Job[T]] {
+		return receive(ctx, jobs)
+	})
+}
+
+// fanOutStream processes the jobs of a sequence, which is created with the context of the jobs.
+func fanOutStream[T any, U any](ctx context.Context, parallelism int, processFunc ProcessFunc[T, U], opts []Option, newJobs func(context.Context) iter.Seq[Job[T]]) <-chan Result[T, U] {
+	parallelism = max(parallelism, 1)
+	results := make(chan Result[T, U], parallelism)
+
+	cfg := newConfig(opts)
+	ctx, errs := errmode.New(ctx, cfg.errorMode, cfg.errorReport)
+	// send sends a result, unless the context is done first.
+	send := func(result Result[T, U]) {
+		select {
+		case <-ctx.Done():
+		case results <- result:
+		}
+	}
+	var order *reorder.Buffer[Result[T, U]]
+	if cfg.orderWindow > 0 {
+		order = reorder.New(cfg.orderWindow, send)
+	}
+	slots := make(chan struct{}, parallelism)
+
+	go func() {
+		var wg sync.WaitGroup
+		defer func() {
+			// Close the results channel once all workers are done.
+			wg.Wait()
+			errs.Finish()
+			close(results)
+		}()
+
+		// Pull the jobs one at a time, so a job is only taken once it has a slot and a place in the order of results.
+		next, stop := iter.Pull(newJobs(ctx))
+		defer stop()
+
+		started := 0
+		for {
+			var seq uint64
+			if order != nil {
+				var ok bool
+				if seq, ok = order.Acquire(ctx, nil); !ok {
+					slog.Info("shutting down goroutine", "reason", ctx.Err(), "started jobs", started)
+					return
+				}
+			}
+			if !acquire(ctx, slots) {
+				slog.Info("shutting down goroutine", "reason", ctx.Err(), "started jobs", started)
+				return
+			}
+			job, ok := next()
+			if !ok {
+				<-slots
+				if order != nil {
+					order.Skip(seq)
+				}
+				return // no more jobs, or the context is done
+			}
+
+			started++
+			wg.Add(1)
+			go func(job Job[T]) {
+				defer wg.Done()
+				defer func() { <-slots }() // Free the slot once the result is sent.
+
+				value, err := panics.Process(ctx, processFunc, job.Value)
+				errs.Record(job.ID, err)
+				result := Result[T, U]{Job: job, Value: value, Err: err}
+				if order != nil {
+					order.Release(seq, result) // Hold the result back until all earlier results are sent.
+					return
+				}
+				send(result)
+			}(job)
+		}
+	}()
+
+	return results
+}
+
+// acquire waits for a free slot, it returns false if the context is done first.
+func acquire(ctx context.Context, slots chan<- struct{}) bool {
+	if ctx.Err() != nil {
+		return false // don't start another job if a slot happens to be free as well
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case slots <- struct{}{}:
+		return true
+	}
+}
+
+// receive returns a sequence of the jobs read from a channel, until it is closed or the context is done.
+func receive[T any](ctx context.Context, jobs <-chan Job[T]) iter.Seq[Job[T]] {
+	return func(yield func(Job[T]) bool) {
+		for ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case job, ok := <-jobs:
+				if !ok || !yield(job) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package fanoutin
+
+import (
+	"context"
+	"iter"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// count returns a sequence of n jobs, without materialising them.
+func count(n int) iter.Seq[Job[int]] {
+	return func(yield func(Job[int]) bool) {
+		for i := 1; i <= n; i++ {
+			if !yield(Job[int]{ID: i, Value: i}) {
+				return
+			}
+		}
+	}
+}
+
+func TestFanOutSeq(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	var inFlight, maxInFlight atomic.Int32
+	process := func(ctx context.Context, value int) (int, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return squareNonNegative(ctx, value)
+	}
+
+	results := FanOutSeq(ctx, count(100), 4, process)
+
+	sum := 0
+	for result := range results {
+		require.NoError(t, result.Err)
+		assert.Equal(t, result.Job.Value*result.Job.Value, result.Value)
+		sum += result.Job.Value
+	}
+	assert.Equal(t, 5050, sum, "every job is processed once")
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(4))
+}
+
+func TestFanOutChan(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	jobs := make(chan Job[int])
+	go func() {
+		defer close(jobs)
+		for job := range count(10) {
+			jobs <- job
+		}
+	}()
+
+	results := FanOutChan(ctx, jobs, 3, squareNonNegative, WithOrderedResults(3))
+
+	var got []int
+	for result := range results {
+		require.NoError(t, result.Err)
+		got = append(got, result.Value)
+	}
+	assert.Equal(t, []int{1, 4, 9, 16, 25, 36, 49, 64, 81, 100}, got)
+}
+
+func TestFanOutStreamCancelled(t *testing.T) {
+	block := func(ctx context.Context, value int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+
+	tests := []struct {
+		name  string
+		start func(ctx context.Context) <-chan Result[int, int]
+	}{
+		{
+			name: "Waiting for a free slot",
+			start: func(ctx context.Context) <-chan Result[int, int] {
+				return FanOutSeq(ctx, count(1_000_000), 2, block)
+			},
+		},
+		{
+			name: "Waiting for a job",
+			start: func(ctx context.Context) <-chan Result[int, int] {
+				return FanOutChan(ctx, make(chan Job[int]), 2, block) // the jobs channel is never closed
+			},
+		},
+		{
+			name: "Waiting to send a result",
+			start: func(ctx context.Context) <-chan Result[int, int] {
+				return FanOutSeq(ctx, count(1_000_000), 2, squareNonNegative) // nobody reads the results
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			results := tt.start(ctx)
+
+			time.Sleep(50 * time.Millisecond) // let the fan-out get stuck
+			cancel()
+
+			closed := make(chan int)
+			go func() {
+				received := 0
+				for range results {
+					received++
+				}
+				closed <- received
+			}()
+			select {
+			case received := <-closed:
+				assert.LessOrEqual(t, received, 4, "at most one result per slot and one buffered result per slot")
+			case <-time.After(time.Second):
+				t.Fatal("results channel was not closed after the context was cancelled")
+			}
+		})
+	}
+}
+
+func TestFanOutChanLeavesJobsWithoutSlot(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	started := make(chan struct{})
+	block := func(ctx context.Context, value int) (int, error) {
+		close(started)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+
+	jobs := make(chan Job[int], 2)
+	jobs <- Job[int]{ID: 1, Value: 1}
+	jobs <- Job[int]{ID: 2, Value: 2}
+	results := FanOutChan(ctx, jobs, 1, block)
+
+	<-started
+	time.Sleep(20 * time.Millisecond) // let the fan-out wait for a free slot
+	cancel()
+	for range results {
+	}
+
+	// The second job never got a slot, so it is still in the jobs channel.
+	require.Len(t, jobs, 1)
+	assert.Equal(t, 2, (<-jobs).ID)
+}
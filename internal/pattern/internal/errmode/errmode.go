@@ -0,0 +1,117 @@
+// Package errmode implements the error modes shared by the executors in the pattern packages.
+package errmode
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Mode decides how an executor handles the errors of its jobs.
+type Mode int
+
+const (
+	Continue   Mode = iota // keep going after errors, the errors are only reported in the results
+	FailFast               // cancel the outstanding jobs on the first error, and report it
+	CollectAll             // finish all jobs, and report all errors joined
+)
+
+// JobError attributes an error to the job that returned it.
+type JobError struct {
+	JobID int
+	Err   error
+}
+
+func (e *JobError) Error() string {
+	return fmt.Sprintf("job %d: %v", e.JobID, e.Err)
+}
+
+func (e *JobError) Unwrap() error {
+	return e.Err
+}
+
+// Report receives the error summary of an executor once it has finished.
+// The zero value is ready to use.
+type Report struct {
+	mu  sync.Mutex
+	err error
+}
+
+// Err returns the error summary, once the executor has finished and closed its results channel.
+// In FailFast mode it is the JobError of the first failed job, in CollectAll mode the JobError of every failed job joined,
+// ordered by job ID. It is nil if no job failed.
+func (r *Report) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.err
+}
+
+// Collector records the errors of the jobs of an executor according to a mode.
+type Collector struct {
+	mode   Mode
+	report *Report
+	cancel context.CancelCauseFunc
+
+	mu   sync.Mutex
+	errs []*JobError
+}
+
+// New returns a collector for the given mode and report, which may be nil,
+// and the context the executor should run its jobs with.
+// In FailFast mode the context is cancelled on the first error, with its JobError as the cause.
+func New(ctx context.Context, mode Mode, report *Report) (context.Context, *Collector) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return ctx, &Collector{mode: mode, report: report, cancel: cancel}
+}
+
+// Record records the error of a job, nil errors are ignored.
+func (c *Collector) Record(jobID int, err error) {
+	if err == nil || c.mode == Continue {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.mode == FailFast {
+		if len(c.errs) > 0 {
+			return // the first error already cancelled the outstanding jobs, later errors are its consequences
+		}
+		c.cancel(&JobError{JobID: jobID, Err: err})
+	}
+	c.errs = append(c.errs, &JobError{JobID: jobID, Err: err})
+}
+
+// Finish writes the error summary to the report and releases the context, it must be called once all jobs are done.
+func (c *Collector) Finish() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cancel(context.Canceled)
+	if c.report == nil || len(c.errs) == 0 {
+		return
+	}
+
+	var err error
+	switch c.mode {
+	case FailFast:
+		err = c.errs[0]
+	case CollectAll:
+		slices.SortStableFunc(c.errs, func(a, b *JobError) int {
+			return cmp.Compare(a.JobID, b.JobID)
+		})
+		errs := make([]error, len(c.errs))
+		for i, jobErr := range c.errs {
+			errs[i] = jobErr
+		}
+		err = errors.Join(errs...)
+	}
+
+	c.report.mu.Lock()
+	c.report.err = err
+	c.report.mu.Unlock()
+}
@@ -0,0 +1,77 @@
+package errmode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	errA := errors.New("a")
+	errB := errors.New("b")
+
+	tests := []struct {
+		name          string
+		mode          Mode
+		wantErr       string
+		wantCancelled bool
+	}{
+		{
+			name: "Continue",
+			mode: Continue,
+		},
+		{
+			name:          "Fail fast",
+			mode:          FailFast,
+			wantErr:       "job 3: a",
+			wantCancelled: true,
+		},
+		{
+			name:    "Collect all",
+			mode:    CollectAll,
+			wantErr: "job 1: b\njob 3: a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var report Report
+			ctx, collector := New(context.Background(), tt.mode, &report)
+
+			collector.Record(2, nil)
+			collector.Record(3, errA)
+			collector.Record(1, errB)
+			assert.Equal(t, tt.wantCancelled, ctx.Err() != nil)
+			assert.NoError(t, report.Err()) // not finished yet
+
+			collector.Finish()
+			if tt.wantErr == "" {
+				assert.NoError(t, report.Err())
+				return
+			}
+			assert.EqualError(t, report.Err(), tt.wantErr)
+			assert.ErrorIs(t, report.Err(), errA)
+
+			var jobErr *JobError
+			assert.ErrorAs(t, report.Err(), &jobErr)
+			if tt.mode == FailFast {
+				assert.Equal(t, jobErr, context.Cause(ctx))
+			}
+		})
+	}
+}
+
+func TestCollectorOrdersExtremeIDs(t *testing.T) {
+	var report Report
+	_, collector := New(context.Background(), CollectAll, &report)
+
+	collector.Record(math.MaxInt, errors.New("a"))
+	collector.Record(math.MinInt, errors.New("b"))
+	collector.Finish()
+
+	assert.EqualError(t, report.Err(), fmt.Sprintf("job %d: b\njob %d: a", math.MinInt, math.MaxInt))
+}
@@ -0,0 +1,41 @@
+// Package panics recovers the panics of the process funcs run by the executors in the pattern packages.
+package panics
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// Error is reported in place of the error of a process func that panicked.
+type Error struct {
+	Value any    // value passed to panic
+	Stack []byte // stack trace of the panicking goroutine
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("process func panicked: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *Error) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Process calls process and recovers a panic into an Error.
+func Process[T any, U any](ctx context.Context, process func(context.Context, T) (U, error), value T) (U, error) {
+	var (
+		result U
+		err    error
+	)
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &Error{Value: r, Stack: debug.Stack()}
+			}
+		}()
+		result, err = process(ctx, value)
+	}()
+	return result, err
+}
@@ -0,0 +1,53 @@
+package panics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcess(t *testing.T) {
+	errNegative := errors.New("negative value")
+	process := func(_ context.Context, value int) (int, error) {
+		switch {
+		case value < 0:
+			panic(errNegative)
+		case value == 0:
+			panic("zero value")
+		}
+		return value * value, nil
+	}
+
+	tests := []struct {
+		name      string
+		value     int
+		want      int
+		wantPanic any
+	}{
+		{name: "No panic", value: 3, want: 9},
+		{name: "Panic with an error", value: -1, wantPanic: errNegative},
+		{name: "Panic with a value", value: 0, wantPanic: "zero value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Process(context.Background(), process, tt.value)
+			assert.Equal(t, tt.want, got)
+			if tt.wantPanic == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var panicErr *Error
+			require.ErrorAs(t, err, &panicErr)
+			assert.Equal(t, tt.wantPanic, panicErr.Value)
+			assert.NotEmpty(t, panicErr.Stack)
+			if wantErr, ok := tt.wantPanic.(error); ok {
+				assert.ErrorIs(t, err, wantErr)
+			}
+		})
+	}
+}
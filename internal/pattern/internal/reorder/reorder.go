@@ -0,0 +1,79 @@
+// Package reorder releases values produced out of order in the order they were submitted.
+package reorder
+
+import (
+	"context"
+	"sync"
+)
+
+// Buffer hands out sequence numbers in submission order and releases completed values in the same order.
+// At most window sequence numbers can be outstanding, Acquire blocks when the window is full.
+type Buffer[V any] struct {
+	slots chan struct{} // semaphore bounding the number of outstanding sequence numbers
+	emit  func(V)
+
+	mu      sync.Mutex
+	seq     uint64        // next sequence number to hand out
+	next    uint64        // next sequence number to release
+	pending map[uint64]*V // completed values waiting for their turn, nil for skipped sequence numbers
+}
+
+// New creates a buffer that calls emit with every value in submission order.
+// Values of the window below 1 are treated as 1.
+func New[V any](window int, emit func(V)) *Buffer[V] {
+	return &Buffer[V]{
+		slots:   make(chan struct{}, max(window, 1)),
+		emit:    emit,
+		pending: make(map[uint64]*V),
+	}
+}
+
+// Acquire reserves the next sequence number, blocking while the window is full.
+// It returns false if the context or the done channel is done first, done may be nil.
+func (b *Buffer[V]) Acquire(ctx context.Context, done <-chan struct{}) (uint64, bool) {
+	select {
+	case <-ctx.Done():
+		return 0, false
+	case <-done:
+		return 0, false
+	case b.slots <- struct{}{}:
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	seq := b.seq
+	b.seq++
+	return seq, true
+}
+
+// Release completes the given sequence number with a value.
+// The value and any values waiting on it are emitted before Release returns.
+func (b *Buffer[V]) Release(seq uint64, v V) {
+	b.complete(seq, &v)
+}
+
+// Skip completes the given sequence number without a value, so later values are not held back.
+func (b *Buffer[V]) Skip(seq uint64) {
+	b.complete(seq, nil)
+}
+
+func (b *Buffer[V]) complete(seq uint64, v *V) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pending[seq] = v
+	for {
+		next, ok := b.pending[b.next]
+		if !ok {
+			return
+		}
+		delete(b.pending, b.next)
+		b.next++
+		<-b.slots
+
+		if next != nil {
+			b.emit(*next) // emitting under the lock keeps values in order
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package reorder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuffer(t *testing.T) {
+	var got []int
+	buffer := New(3, func(v int) { got = append(got, v) })
+
+	ctx := context.Background()
+	for want := uint64(0); want < 3; want++ {
+		seq, ok := buffer.Acquire(ctx, nil)
+		require.True(t, ok)
+		assert.Equal(t, want, seq)
+	}
+
+	// The window is full until the oldest value is released.
+	ctxTimeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, ok := buffer.Acquire(ctxTimeout, nil)
+	assert.False(t, ok)
+
+	buffer.Release(2, 2)
+	buffer.Skip(1)
+	assert.Empty(t, got)
+
+	buffer.Release(0, 0)
+	assert.Equal(t, []int{0, 2}, got)
+
+	seq, ok := buffer.Acquire(ctx, nil)
+	require.True(t, ok)
+	assert.Equal(t, uint64(3), seq)
+	buffer.Release(3, 3)
+	assert.Equal(t, []int{0, 2, 3}, got)
+}
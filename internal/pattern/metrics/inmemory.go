@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the histogram upper bounds, in seconds, used by NewInMemory.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Snapshot is a point-in-time copy of the job counters and gauges.
+type Snapshot struct {
+	Queued    int64 // jobs accepted but not started
+	InFlight  int64 // jobs being processed
+	Completed int64 // jobs finished without an error
+	Failed    int64 // jobs finished with an error
+	Dropped   int64 // jobs accepted but never processed
+}
+
+// InMemory is a Metrics implementation that keeps counters, gauges and histograms in memory.
+// It implements http.Handler and serves its metrics in the Prometheus text exposition format.
+type InMemory struct {
+	namespace string
+
+	mu         sync.Mutex
+	snapshot   Snapshot
+	queueWait  histogram
+	processing histogram
+}
+
+// NewInMemory creates an in-memory metrics collector, metric names are prefixed with the namespace.
+func NewInMemory(namespace string) *InMemory {
+	return &InMemory{
+		namespace:  namespace,
+		queueWait:  newHistogram(DefaultBuckets),
+		processing: newHistogram(DefaultBuckets),
+	}
+}
+
+func (m *InMemory) JobQueued() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.snapshot.Queued++
+}
+
+func (m *InMemory) JobStarted(wait time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.snapshot.Queued--
+	m.snapshot.InFlight++
+	m.queueWait.observe(wait.Seconds())
+}
+
+func (m *InMemory) JobFinished(latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.snapshot.InFlight--
+	if err != nil {
+		m.snapshot.Failed++
+	} else {
+		m.snapshot.Completed++
+	}
+	m.processing.observe(latency.Seconds())
+}
+
+func (m *InMemory) JobDropped() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.snapshot.Queued--
+	m.snapshot.Dropped++
+}
+
+// Snapshot returns the current values of the job counters and gauges.
+func (m *InMemory) Snapshot() Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.snapshot
+}
+
+// WriteTo writes all metrics to w in the Prometheus text exposition format.
+func (m *InMemory) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	snapshot := m.snapshot
+	queueWait := m.queueWait.clone()
+	processing := m.processing.clone()
+	m.mu.Unlock()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	m.writeMetric(cw, "jobs_queued", "gauge", "Number of jobs accepted but not started.", snapshot.Queued)
+	m.writeMetric(cw, "jobs_in_flight", "gauge", "Number of jobs being processed.", snapshot.InFlight)
+	m.writeMetric(cw, "jobs_completed_total", "counter", "Total number of jobs finished without an error.", snapshot.Completed)
+	m.writeMetric(cw, "jobs_failed_total", "counter", "Total number of jobs finished with an error.", snapshot.Failed)
+	m.writeMetric(cw, "jobs_dropped_total", "counter", "Total number of jobs accepted but never processed.", snapshot.Dropped)
+	m.writeHistogram(cw, "queue_wait_seconds", "Time jobs spent waiting before processing started.", queueWait)
+	m.writeHistogram(cw, "processing_seconds", "Time spent processing jobs.", processing)
+
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format.
+func (m *InMemory) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+func (m *InMemory) writeMetric(w *countingWriter, name, kind, help string, value int64) {
+	name = m.name(name)
+	w.printf("# HELP %s %s\n# TYPE %s %s\n%s %d\n", name, help, name, kind, name, value)
+}
+
+func (m *InMemory) writeHistogram(w *countingWriter, name, help string, h histogram) {
+	name = m.name(name)
+	w.printf("# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		w.printf("%s_bucket{le=%q} %d\n", name, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+	}
+	w.printf("%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	w.printf("%s_sum %s\n%s_count %d\n", name, strconv.FormatFloat(h.sum, 'g', -1, 64), name, h.count)
+}
+
+func (m *InMemory) name(name string) string {
+	if m.namespace == "" {
+		return name
+	}
+	return m.namespace + "_" + name
+}
+
+// histogram counts observations in buckets with the given upper bounds.
+type histogram struct {
+	bounds []float64
+	counts []uint64 // non-cumulative count per bucket
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) histogram {
+	return histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(value float64) {
+	h.count++
+	h.sum += value
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.counts[i]++
+			return
+		}
+	}
+}
+
+func (h *histogram) clone() histogram {
+	c := *h
+	c.counts = append([]uint64(nil), h.counts...)
+	return c
+}
+
+// countingWriter keeps the first write error and the number of bytes written.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (w *countingWriter) printf(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.w, format, args...)
+	w.n += int64(n)
+	w.err = err
+}
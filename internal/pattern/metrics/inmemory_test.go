@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemory(t *testing.T) {
+	m := NewInMemory("pool")
+
+	for i := 0; i < 3; i++ {
+		m.JobQueued()
+	}
+	m.JobStarted(time.Millisecond)
+	m.JobStarted(time.Second)
+	m.JobFinished(20*time.Millisecond, nil)
+
+	assert.Equal(t, Snapshot{Queued: 1, InFlight: 1, Completed: 1}, m.Snapshot())
+
+	m.JobFinished(3*time.Second, errors.New("failed"))
+	assert.Equal(t, Snapshot{Queued: 1, Completed: 1, Failed: 1}, m.Snapshot())
+
+	m.JobDropped()
+	assert.Equal(t, Snapshot{Completed: 1, Failed: 1, Dropped: 1}, m.Snapshot())
+}
+
+func TestInMemoryServeHTTP(t *testing.T) {
+	m := NewInMemory("pool")
+	m.JobQueued()
+	m.JobStarted(30 * time.Millisecond)
+	m.JobFinished(2*time.Second, nil)
+
+	recorder := httptest.NewRecorder()
+	m.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	resp := recorder.Result()
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "version=0.0.4")
+	for _, line := range []string{
+		"# TYPE pool_jobs_queued gauge\npool_jobs_queued 0\n",
+		"# TYPE pool_jobs_in_flight gauge\npool_jobs_in_flight 0\n",
+		"# TYPE pool_jobs_completed_total counter\npool_jobs_completed_total 1\n",
+		"# TYPE pool_jobs_failed_total counter\npool_jobs_failed_total 0\n",
+		"# TYPE pool_jobs_dropped_total counter\npool_jobs_dropped_total 0\n",
+		"# TYPE pool_queue_wait_seconds histogram\n",
+		"pool_queue_wait_seconds_bucket{le=\"0.025\"} 0\n",
+		"pool_queue_wait_seconds_bucket{le=\"0.05\"} 1\n",
+		"pool_queue_wait_seconds_bucket{le=\"+Inf\"} 1\n",
+		"pool_queue_wait_seconds_sum 0.03\n",
+		"pool_queue_wait_seconds_count 1\n",
+		"pool_processing_seconds_bucket{le=\"1\"} 0\n",
+		"pool_processing_seconds_bucket{le=\"2.5\"} 1\n",
+		"pool_processing_seconds_sum 2\n",
+	} {
+		assert.Contains(t, string(body), line)
+	}
+}
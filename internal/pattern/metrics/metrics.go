@@ -0,0 +1,27 @@
+// Package metrics provides instrumentation for the concurrent executors in the pattern packages.
+package metrics
+
+import (
+	"time"
+)
+
+// Metrics receives the lifecycle events of the jobs handled by an executor.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// JobQueued is called when an executor accepts a job.
+	JobQueued()
+	// JobStarted is called when a job starts processing, after waiting in the queue for the given duration.
+	JobStarted(wait time.Duration)
+	// JobFinished is called when a job finishes processing after the given duration, err is the job's error if any.
+	JobFinished(latency time.Duration, err error)
+	// JobDropped is called when an executor gives up a queued job without processing it, such as when it stops.
+	JobDropped()
+}
+
+// Nop is a Metrics implementation that discards all events.
+type Nop struct{}
+
+func (Nop) JobQueued()                       {}
+func (Nop) JobStarted(time.Duration)         {}
+func (Nop) JobFinished(time.Duration, error) {}
+func (Nop) JobDropped()                      {}
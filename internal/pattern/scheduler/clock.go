@@ -0,0 +1,36 @@
+package scheduler
+
+import "time"
+
+// Clock tells the time and creates timers, so schedules can be tested without waiting for real time to pass.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by a Clock.
+type Timer interface {
+	// C returns the channel the time is sent on when the timer fires.
+	C() <-chan time.Time
+	// Stop prevents the timer from firing, it returns false if the timer already fired or was stopped.
+	Stop() bool
+}
+
+// realClock is the wall clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
@@ -0,0 +1,193 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCron is wrapped by the errors of ParseCron.
+var ErrInvalidCron = errors.New("invalid cron expression")
+
+// Schedule decides when a recurring job runs.
+type Schedule interface {
+	// Next returns the first run strictly after the given time, or the zero time if there is none.
+	Next(after time.Time) time.Time
+}
+
+// Every returns a schedule that runs every d, counted from the previous run.
+// It panics if d is not positive, as time.NewTicker does.
+func Every(d time.Duration) Schedule {
+	if d <= 0 {
+		panic("scheduler: non-positive interval for Every")
+	}
+	return every(d)
+}
+
+type every time.Duration
+
+func (e every) Next(after time.Time) time.Time {
+	return after.Add(time.Duration(e))
+}
+
+// field describes one field of a cron expression.
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int // names accepted instead of numbers, nil if there are none
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6, "jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{ // both 0 and 7 are Sunday
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// descriptors are the shorthands accepted instead of the five fields.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSchedule is a parsed cron expression, every field is a bit set of the values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool // the field starts with *, see matchesDay
+}
+
+// ParseCron parses a standard cron expression with five fields: minute, hour, day of month, month and day of week.
+// Fields accept *, numbers, ranges such as 1-5, lists such as 1,15 and steps such as */10 or 8-18/2.
+// Months and days of the week also accept their three-letter English names, and both 0 and 7 mean Sunday.
+// The shorthands @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly are accepted as well.
+// Runs are computed in the location of the time passed to Next.
+func ParseCron(expr string) (Schedule, error) {
+	if descriptor, ok := descriptors[strings.ToLower(strings.TrimSpace(expr))]; ok {
+		expr = descriptor
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("%w %q: expected 5 fields, got %d", ErrInvalidCron, expr, len(fields))
+	}
+
+	var s cronSchedule
+	for i, f := range []struct {
+		field
+		bits *uint64
+	}{
+		{minuteField, &s.minute},
+		{hourField, &s.hour},
+		{domField, &s.dom},
+		{monthField, &s.month},
+		{dowField, &s.dow},
+	} {
+		bits, err := f.parse(fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %s: %w", ErrInvalidCron, expr, f.name, err)
+		}
+		*f.bits = bits
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 is Sunday too
+	}
+	s.domStar = strings.HasPrefix(fields[2], "*")
+	s.dowStar = strings.HasPrefix(fields[4], "*")
+	return &s, nil
+}
+
+// parse returns the bit set of the values matched by a comma-separated list of items.
+func (f field) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(expr, ",") {
+		lo, hi, step := f.min, f.max, 1
+
+		rng, stepExpr, hasStep := strings.Cut(item, "/")
+		if hasStep {
+			n, err := strconv.Atoi(stepExpr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepExpr)
+			}
+			step = n
+		}
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(from); err != nil {
+				return 0, err
+			}
+			switch {
+			case isRange:
+				if hi, err = f.value(to); err != nil {
+					return 0, err
+				}
+			case !hasStep:
+				hi = lo // a single value, n/step runs from n to the maximum
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value parses a single number or name of the field.
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %q out of range %d-%d", s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first minute strictly after the given time that matches the expression,
+// or the zero time if none does within five years, such as for the 30th of February.
+func (s *cronSchedule) Next(after time.Time) time.Time {
+	t := after.Add(time.Minute - time.Duration(after.Second())*time.Second - time.Duration(after.Nanosecond()))
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute) // not t.Truncate, which ignores the time zone
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay reports whether the day matches the expression.
+// As in the classic cron, if both the day of month and the day of week are restricted, matching either is enough.
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	// Wednesday, 15 May 2024, 10:07:30 UTC
+	start := time.Date(2024, time.May, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		want []time.Time
+	}{
+		{
+			name: "Every minute",
+			expr: "* * * * *",
+			want: []time.Time{
+				time.Date(2024, time.May, 15, 10, 8, 0, 0, time.UTC),
+				time.Date(2024, time.May, 15, 10, 9, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Steps and lists",
+			expr: "*/20 9,11 * * *",
+			want: []time.Time{
+				time.Date(2024, time.May, 15, 11, 0, 0, 0, time.UTC),
+				time.Date(2024, time.May, 15, 11, 20, 0, 0, time.UTC),
+				time.Date(2024, time.May, 15, 11, 40, 0, 0, time.UTC),
+				time.Date(2024, time.May, 16, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Range with step",
+			expr: "30 8-18/4 * * *",
+			want: []time.Time{
+				time.Date(2024, time.May, 15, 12, 30, 0, 0, time.UTC),
+				time.Date(2024, time.May, 15, 16, 30, 0, 0, time.UTC),
+				time.Date(2024, time.May, 16, 8, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Weekdays by name",
+			expr: "0 9 * * mon-fri",
+			want: []time.Time{
+				time.Date(2024, time.May, 16, 9, 0, 0, 0, time.UTC), // Thursday
+				time.Date(2024, time.May, 17, 9, 0, 0, 0, time.UTC), // Friday
+				time.Date(2024, time.May, 20, 9, 0, 0, 0, time.UTC), // Monday
+			},
+		},
+		{
+			name: "Seven is Sunday",
+			expr: "0 0 * * 7",
+			want: []time.Time{
+				time.Date(2024, time.May, 19, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.May, 26, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Day of month or day of week",
+			expr: "0 0 1 * sun",
+			want: []time.Time{
+				time.Date(2024, time.May, 19, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.May, 26, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.June, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Leap day",
+			expr: "0 12 29 feb *",
+			want: []time.Time{
+				time.Date(2028, time.February, 29, 12, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Descriptor",
+			expr: "@monthly",
+			want: []time.Time{
+				time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "Never",
+			expr: "0 0 30 2 *",
+			want: []time.Time{{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			require.NoError(t, err)
+
+			var got []time.Time
+			next := start
+			for range tt.want {
+				next = schedule.Next(next)
+				got = append(got, next)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"* * * foo *",
+		"@every",
+	} {
+		_, err := ParseCron(expr)
+		assert.ErrorIs(t, err, ErrInvalidCron, expr)
+	}
+}
+
+func TestEvery(t *testing.T) {
+	start := time.Date(2024, time.May, 15, 10, 7, 30, 0, time.UTC)
+
+	assert.Equal(t, start.Add(90*time.Second), Every(90*time.Second).Next(start))
+	assert.Panics(t, func() { Every(0) })
+}
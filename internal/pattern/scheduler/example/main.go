@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/scheduler"
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/workerpool"
+)
+
+// Cleanup pretends to remove the expired entries of a cache.
+func Cleanup(_ context.Context, scheduled time.Time) (string, error) {
+	return "cleaned up entries expired before " + scheduled.Format(time.TimeOnly), nil
+}
+
+func main() {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+
+	jobs := make(chan workerpool.Job[time.Time])
+	results := make(chan workerpool.Result[time.Time, string])
+
+	// The scheduler emits the jobs into the jobs channel of a worker pool, and closes it once the context is done.
+	s := scheduler.New(ctx, jobs)
+	id := 0
+	newJob := func(scheduled time.Time) workerpool.Job[time.Time] {
+		id++
+		return workerpool.Job[time.Time]{ID: id, Value: scheduled}
+	}
+
+	// Run the cleanup soon after starting, and then at the start of every minute.
+	s.After(5*time.Second, newJob(time.Now().Add(5*time.Second)))
+	if _, err := s.Cron("* * * * *", scheduler.CatchUpOnce, newJob); err != nil {
+		slog.Error("Invalid cron expression", "error", err)
+		return
+	}
+
+	workerpool.CreateWorkerPool(ctx, 2, jobs, results, Cleanup)
+
+	for result := range results {
+		if result.Err != nil {
+			slog.Error("Error processing job", "jobID", result.Job.ID, "error", result.Err)
+			continue
+		}
+		slog.Info("Result for job", "jobID", result.Job.ID, "result", result.Value)
+	}
+}
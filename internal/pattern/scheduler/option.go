@@ -0,0 +1,34 @@
+package scheduler
+
+import "time"
+
+// config holds the optional settings of a scheduler.
+type config struct {
+	clock     Clock
+	tolerance time.Duration
+}
+
+// Option configures optional behaviour of a scheduler.
+type Option func(*config)
+
+// WithClock sets the clock the scheduler reads the time from and waits on, the wall clock by default.
+func WithClock(clock Clock) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// WithTolerance sets how late a run of a recurring job may be before it counts as missed, one second by default.
+func WithTolerance(d time.Duration) Option {
+	return func(c *config) {
+		c.tolerance = max(d, 0)
+	}
+}
+
+func newConfig(opts []Option) config {
+	c := config{clock: realClock{}, tolerance: time.Second}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
@@ -0,0 +1,289 @@
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MissedRunPolicy decides what happens to the runs of a recurring job that were missed,
+// because the scheduler was paused, the jobs channel was full or the process was suspended.
+// A run is missed if it is handled later than the tolerance, see WithTolerance.
+type MissedRunPolicy int
+
+const (
+	Skip        MissedRunPolicy = iota // missed runs are dropped
+	CatchUpOnce                        // one job is emitted for all missed runs, for the latest of them
+	CatchUpAll                         // a job is emitted for every missed run, in order
+)
+
+// EntryID identifies a scheduled job, so it can be removed.
+type EntryID int
+
+// Scheduler emits jobs at a future time or on a recurring schedule into a channel,
+// such as the jobs channel of a worker pool.
+type Scheduler[J any] struct {
+	cfg  config
+	out  chan<- J
+	wake chan struct{} // signals the scheduler to look at its entries again
+	done chan struct{} // closed after the out channel is closed
+
+	mu      sync.Mutex
+	entries entryQueue[J]
+	byID    map[EntryID]*entry[J]
+	lastID  EntryID
+	paused  bool
+}
+
+// entry is a scheduled job.
+type entry[J any] struct {
+	id       EntryID
+	next     time.Time // time of the next run
+	schedule Schedule  // nil for a one-off job
+	policy   MissedRunPolicy
+	newJob   func(time.Time) J
+	index    int // index in the entry queue
+}
+
+// run is a job due to be emitted.
+type run[J any] struct {
+	at     time.Time
+	newJob func(time.Time) J
+}
+
+// New creates a scheduler that emits jobs into out until the context is done, then closes out.
+func New[J any](ctx context.Context, out chan<- J, opts ...Option) *Scheduler[J] {
+	s := &Scheduler[J]{
+		cfg:  newConfig(opts),
+		out:  out,
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+		byID: map[EntryID]*entry[J]{},
+	}
+	go s.run(ctx)
+
+	return s
+}
+
+// After emits job once, after d.
+// One-off jobs are always emitted, however late, missed-run policies only apply to recurring jobs.
+func (s *Scheduler[J]) After(d time.Duration, job J) EntryID {
+	return s.At(s.cfg.clock.Now().Add(d), job)
+}
+
+// At emits job once, at t, see After.
+func (s *Scheduler[J]) At(t time.Time, job J) EntryID {
+	return s.add(&entry[J]{next: t, newJob: func(time.Time) J { return job }})
+}
+
+// Cron emits a job for every run of a cron expression, see ParseCron.
+// newJob is called with the scheduled time of every run, so every job can get its own ID.
+func (s *Scheduler[J]) Cron(expr string, policy MissedRunPolicy, newJob func(time.Time) J) (EntryID, error) {
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return 0, err
+	}
+	return s.Repeat(schedule, policy, newJob), nil
+}
+
+// Repeat emits a job for every run of a schedule, starting with the first run after now, see Cron.
+func (s *Scheduler[J]) Repeat(schedule Schedule, policy MissedRunPolicy, newJob func(time.Time) J) EntryID {
+	return s.add(&entry[J]{
+		next:     schedule.Next(s.cfg.clock.Now()),
+		schedule: schedule,
+		policy:   policy,
+		newJob:   newJob,
+	})
+}
+
+// Remove removes a scheduled job, it returns false if the job is unknown or was already emitted for the last time.
+func (s *Scheduler[J]) Remove(id EntryID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.byID[id]
+	if !ok {
+		return false
+	}
+	heap.Remove(&s.entries, e.index)
+	delete(s.byID, id)
+	s.signal()
+	return true
+}
+
+// Pause stops emitting jobs until Resume is called, runs that become due meanwhile are handled by their missed-run policy.
+func (s *Scheduler[J]) Pause() {
+	s.setPaused(true)
+}
+
+// Resume starts emitting jobs again after Pause.
+func (s *Scheduler[J]) Resume() {
+	s.setPaused(false)
+}
+
+// Wait blocks until the scheduler has stopped and the out channel is closed.
+func (s *Scheduler[J]) Wait() {
+	<-s.done
+}
+
+func (s *Scheduler[J]) setPaused(paused bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.paused = paused
+	s.signal()
+}
+
+// add schedules an entry, a recurring entry without runs is never scheduled.
+func (s *Scheduler[J]) add(e *entry[J]) EntryID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastID++
+	e.id = s.lastID
+	if e.next.IsZero() {
+		return e.id
+	}
+	heap.Push(&s.entries, e)
+	s.byID[e.id] = e
+	s.signal()
+	return e.id
+}
+
+// signal wakes the scheduler up without blocking, one pending signal is enough.
+func (s *Scheduler[J]) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run waits for the next run and emits the due jobs, until the context is done.
+func (s *Scheduler[J]) run(ctx context.Context) {
+	defer close(s.done)
+	defer close(s.out)
+
+	for {
+		fire, stop := s.nextRun()
+		select {
+		case <-ctx.Done():
+			stop()
+			return
+		case <-s.wake:
+			stop()
+		case <-fire:
+			if !s.emit(ctx, s.due(s.cfg.clock.Now())) {
+				return
+			}
+		}
+	}
+}
+
+// nextRun starts a timer for the earliest run, and returns its channel and a func to stop it.
+// The channel is nil while the scheduler is paused or has no entries.
+func (s *Scheduler[J]) nextRun() (<-chan time.Time, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paused || len(s.entries) == 0 {
+		return nil, func() {}
+	}
+	timer := s.cfg.clock.NewTimer(s.entries[0].next.Sub(s.cfg.clock.Now()))
+	return timer.C(), func() { timer.Stop() }
+}
+
+// due collects the due runs of all entries in order, and moves the entries on to their next run.
+func (s *Scheduler[J]) due(now time.Time) []run[J] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paused {
+		return nil
+	}
+	var runs []run[J]
+	for len(s.entries) > 0 && !s.entries[0].next.After(now) {
+		e := s.entries[0]
+		runs = append(runs, e.due(now, s.cfg.tolerance)...)
+		if e.next.IsZero() {
+			heap.Pop(&s.entries)
+			delete(s.byID, e.id)
+			continue
+		}
+		heap.Fix(&s.entries, 0)
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].at.Before(runs[j].at)
+	})
+	return runs
+}
+
+// emit creates and sends the jobs of the runs, it returns false if the context is done first.
+// Jobs are created outside the lock, so newJob may call the scheduler.
+func (s *Scheduler[J]) emit(ctx context.Context, runs []run[J]) bool {
+	for _, r := range runs {
+		select {
+		case <-ctx.Done():
+			return false
+		case s.out <- r.newJob(r.at):
+		}
+	}
+	return true
+}
+
+// due returns the runs of the entry up to now according to its missed-run policy, and moves it on to its next run.
+// The next run is the zero time once there are no more runs.
+func (e *entry[J]) due(now time.Time, tolerance time.Duration) []run[J] {
+	if e.schedule == nil {
+		r := run[J]{at: e.next, newJob: e.newJob}
+		e.next = time.Time{}
+		return []run[J]{r}
+	}
+
+	var runs []run[J]
+	var latestMissed time.Time
+	t := e.next
+	for ; !t.IsZero() && !t.After(now); t = e.schedule.Next(t) {
+		switch {
+		case now.Sub(t) <= tolerance:
+			runs = append(runs, run[J]{at: t, newJob: e.newJob})
+		case e.policy == CatchUpOnce:
+			latestMissed = t
+		case e.policy == CatchUpAll:
+			runs = append(runs, run[J]{at: t, newJob: e.newJob})
+		}
+	}
+	e.next = t
+	if !latestMissed.IsZero() {
+		runs = append([]run[J]{{at: latestMissed, newJob: e.newJob}}, runs...)
+	}
+	return runs
+}
+
+// entryQueue implements heap.Interface, the entry with the earliest next run is at the top.
+type entryQueue[J any] []*entry[J]
+
+func (q entryQueue[J]) Len() int { return len(q) }
+
+func (q entryQueue[J]) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+
+func (q entryQueue[J]) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *entryQueue[J]) Push(x any) {
+	e, _ := x.(*entry[J])
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *entryQueue[J]) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return e
+}
@@ -0,0 +1,177 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a clock that only moves when it is advanced.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers map[*fakeTimer]struct{}
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, timers: map[*fakeTimer]struct{}{}}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.timers[t] = struct{}{}
+	return t
+}
+
+// Advance moves the clock forward, firing the timers that are due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	for t := range c.timers {
+		if !t.at.After(c.now) {
+			t.c <- c.now
+			delete(c.timers, t)
+		}
+	}
+}
+
+type fakeTimer struct {
+	clock *fakeClock
+	at    time.Time
+	c     chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	_, pending := t.clock.timers[t]
+	delete(t.clock.timers, t)
+	return pending
+}
+
+// receive returns the jobs emitted within a short time.
+func receive[J any](out <-chan J) []J {
+	var jobs []J
+	for {
+		select {
+		case job := <-out:
+			jobs = append(jobs, job)
+		case <-time.After(50 * time.Millisecond):
+			return jobs
+		}
+	}
+}
+
+func TestSchedulerOneOff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	clock := newFakeClock(time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC))
+	out := make(chan string, 10)
+	s := New(ctx, out, WithClock(clock))
+
+	s.After(2*time.Second, "second")
+	s.After(time.Second, "first")
+	removed := s.After(time.Second, "removed")
+	assert.True(t, s.Remove(removed))
+	assert.False(t, s.Remove(removed))
+
+	clock.Advance(500 * time.Millisecond)
+	assert.Empty(t, receive(out))
+
+	// One-off jobs are emitted in order, however late.
+	clock.Advance(time.Hour)
+	assert.Equal(t, []string{"first", "second"}, receive(out))
+
+	cancel()
+	s.Wait()
+	_, open := <-out
+	assert.False(t, open, "out is closed once the context is done")
+}
+
+func TestSchedulerMissedRunPolicy(t *testing.T) {
+	start := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	tests := []struct {
+		name   string
+		policy MissedRunPolicy
+		want   []time.Time // emitted after runs 2 to 4 were missed while paused
+	}{
+		{
+			name:   "Skip",
+			policy: Skip,
+			want:   []time.Time{at(5)},
+		},
+		{
+			name:   "Catch up once",
+			policy: CatchUpOnce,
+			want:   []time.Time{at(4), at(5)},
+		},
+		{
+			name:   "Catch up all",
+			policy: CatchUpAll,
+			want:   []time.Time{at(2), at(3), at(4), at(5)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			clock := newFakeClock(start)
+			out := make(chan time.Time, 10)
+			s := New(ctx, out, WithClock(clock))
+			_, err := s.Cron("* * * * *", tt.policy, func(at time.Time) time.Time { return at })
+			require.NoError(t, err)
+
+			clock.Advance(time.Minute)
+			assert.Equal(t, []time.Time{at(1)}, receive(out))
+
+			s.Pause()
+			clock.Advance(3*time.Minute + 30*time.Second)
+			assert.Empty(t, receive(out))
+			s.Resume()
+			clock.Advance(30 * time.Second)
+			assert.Equal(t, tt.want, receive(out))
+
+			clock.Advance(time.Minute)
+			assert.Equal(t, []time.Time{at(6)}, receive(out))
+		})
+	}
+}
+
+func TestSchedulerCronInvalid(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	s := New(ctx, make(chan int))
+	_, err := s.Cron("every minute", Skip, func(time.Time) int { return 0 })
+	assert.ErrorIs(t, err, ErrInvalidCron)
+}
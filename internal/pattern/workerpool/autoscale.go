@@ -0,0 +1,166 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/metrics"
+)
+
+// ScaleReason describes why an autoscaling pool changed its number of workers.
+type ScaleReason string
+
+const (
+	ScaleReasonBacklog   ScaleReason = "backlog"    // too many jobs are waiting for a worker
+	ScaleReasonQueueWait ScaleReason = "queue wait" // jobs waited too long for a worker
+	ScaleReasonIdle      ScaleReason = "idle"       // workers were idle for the cool-down period
+)
+
+// ScaleEvent describes a scaling decision of an autoscaling pool.
+type ScaleEvent struct {
+	Time   time.Time
+	From   int // number of workers before the decision
+	To     int // number of workers after the decision
+	Reason ScaleReason
+}
+
+// AutoscaleConfig configures an autoscaling pool.
+type AutoscaleConfig struct {
+	MinWorkers int // lower bound of the number of workers, at least 1
+	MaxWorkers int // upper bound of the number of workers
+
+	BacklogThreshold   int           // add workers when more jobs than this are waiting, zero disables the check
+	QueueWaitThreshold time.Duration // add workers when a job waited longer than this, zero disables the check
+	IdleCooldown       time.Duration // retire one worker after workers were idle for this long, defaults to one second
+	Interval           time.Duration // how often scaling decisions are made, defaults to 100ms
+
+	OnScale func(ScaleEvent) // called for every scaling decision, may be nil
+}
+
+// CreateAutoscalingWorkerPool creates a pool of workers that grows and shrinks between MinWorkers and MaxWorkers.
+// Workers are added while the backlog or the queue wait crosses its threshold,
+// and retired one at a time after they were idle for the cool-down period.
+// The backlog includes jobs buffered in the jobs channel.
+func CreateAutoscalingWorkerPool[T any, U any](ctx context.Context, cfg AutoscaleConfig, jobs <-chan Job[T], results chan<- Result[T, U], process ProcessFunc[T, U], opts ...Option) *WorkerPool[T, U] {
+	cfg.MinWorkers = max(cfg.MinWorkers, 1)
+	cfg.MaxWorkers = max(cfg.MaxWorkers, cfg.MinWorkers)
+	if cfg.IdleCooldown <= 0 {
+		cfg.IdleCooldown = time.Second
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 100 * time.Millisecond
+	}
+
+	// Observe the jobs through the pool's metrics, passing every event on to the configured metrics.
+	observer := &scaleObserver{}
+	opts = append(opts, func(c *config) {
+		observer.next = c.metrics
+		c.metrics = observer
+	})
+
+	pool := CreateWorkerPool(ctx, cfg.MinWorkers, jobs, results, process, opts...)
+	go autoscale(pool, cfg, observer, func() int { return len(jobs) })
+
+	return pool
+}
+
+// autoscale periodically resizes the pool until it is closed.
+func autoscale[T any, U any](pool *WorkerPool[T, U], cfg AutoscaleConfig, observer *scaleObserver, buffered func() int) {
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	var idleSince time.Time
+	for {
+		select {
+		case <-pool.closed:
+			return
+		case now := <-ticker.C:
+			queued, inFlight, maxWait := observer.observe()
+			backlog := queued + buffered()
+			size := pool.Size()
+
+			idle := inFlight < size && backlog == 0
+			switch {
+			case !idle:
+				idleSince = time.Time{}
+			case idleSince.IsZero():
+				idleSince = now
+			}
+
+			to, reason := size, ScaleReason("")
+			switch {
+			case cfg.BacklogThreshold > 0 && backlog > cfg.BacklogThreshold:
+				to, reason = size+backlog-cfg.BacklogThreshold, ScaleReasonBacklog
+			case cfg.QueueWaitThreshold > 0 && maxWait > cfg.QueueWaitThreshold:
+				to, reason = size+1, ScaleReasonQueueWait
+			case idle && now.Sub(idleSince) >= cfg.IdleCooldown:
+				to, reason = size-1, ScaleReasonIdle
+				idleSince = now // the cool-down starts over for the next worker
+			}
+
+			to = min(max(to, cfg.MinWorkers), cfg.MaxWorkers)
+			if to == size || pool.isDone() {
+				continue
+			}
+			pool.Resize(to)
+			if cfg.OnScale != nil {
+				cfg.OnScale(ScaleEvent{Time: now, From: size, To: to, Reason: reason})
+			}
+		}
+	}
+}
+
+// scaleObserver tracks the queue of a pool for autoscaling decisions.
+type scaleObserver struct {
+	next metrics.Metrics
+
+	mu       sync.Mutex
+	queued   int
+	inFlight int
+	maxWait  time.Duration // longest queue wait since the last observation
+}
+
+func (o *scaleObserver) JobQueued() {
+	o.mu.Lock()
+	o.queued++
+	o.mu.Unlock()
+
+	o.next.JobQueued()
+}
+
+func (o *scaleObserver) JobStarted(wait time.Duration) {
+	o.mu.Lock()
+	o.queued--
+	o.inFlight++
+	o.maxWait = max(o.maxWait, wait)
+	o.mu.Unlock()
+
+	o.next.JobStarted(wait)
+}
+
+func (o *scaleObserver) JobFinished(latency time.Duration, err error) {
+	o.mu.Lock()
+	o.inFlight--
+	o.mu.Unlock()
+
+	o.next.JobFinished(latency, err)
+}
+
+func (o *scaleObserver) JobDropped() {
+	o.mu.Lock()
+	o.queued--
+	o.mu.Unlock()
+
+	o.next.JobDropped()
+}
+
+// observe returns the number of queued and in-flight jobs and the longest queue wait since the last call.
+func (o *scaleObserver) observe() (int, int, time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	maxWait := o.maxWait
+	o.maxWait = 0
+	return o.queued, o.inFlight, maxWait
+}
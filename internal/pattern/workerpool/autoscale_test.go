@@ -0,0 +1,71 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoscalingWorkerPool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	var (
+		mu     sync.Mutex
+		events []ScaleEvent
+	)
+	cfg := AutoscaleConfig{
+		MinWorkers:       1,
+		MaxWorkers:       4,
+		BacklogThreshold: 1,
+		IdleCooldown:     20 * time.Millisecond,
+		Interval:         5 * time.Millisecond,
+		OnScale: func(event ScaleEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		},
+	}
+
+	const numJobs = 20
+	release := make(chan struct{})
+	process := func(_ context.Context, value int) (int, error) {
+		<-release
+		return value, nil
+	}
+
+	jobsChan := make(chan Job[int], numJobs)
+	resultsChan := make(chan Result[int, int], numJobs)
+	pool := CreateAutoscalingWorkerPool(ctx, cfg, jobsChan, resultsChan, process)
+	assert.Equal(t, 1, pool.Size())
+
+	for i := 0; i < numJobs; i++ {
+		jobsChan <- Job[int]{ID: i, Value: i}
+	}
+
+	// The backlog grows the pool up to its maximum.
+	assert.Eventually(t, func() bool { return pool.Size() == cfg.MaxWorkers }, time.Second, time.Millisecond)
+
+	// Idle workers are retired down to the minimum once the backlog is gone.
+	close(release)
+	for i := 0; i < numJobs; i++ {
+		<-resultsChan
+	}
+	assert.Eventually(t, func() bool { return pool.Size() == cfg.MinWorkers }, time.Second, time.Millisecond)
+
+	close(jobsChan)
+	pool.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, ScaleReasonBacklog, events[0].Reason)
+	assert.Equal(t, ScaleEvent{Time: events[len(events)-1].Time, From: 2, To: 1, Reason: ScaleReasonIdle}, events[len(events)-1])
+	for _, event := range events {
+		assert.NotEqual(t, event.From, event.To)
+		assert.GreaterOrEqual(t, event.To, cfg.MinWorkers)
+		assert.LessOrEqual(t, event.To, cfg.MaxWorkers)
+	}
+}
@@ -0,0 +1,249 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/errmode"
+)
+
+// ErrBatchSize is reported for every item of a batch when a BatchProcessFunc returns the wrong number of values.
+var ErrBatchSize = errors.New("batch process func returned the wrong number of values")
+
+// BatchProcessFunc processes a batch of values and returns one value per item, in the same order.
+// Per-item failures are reported with a BatchError, any other error fails the whole batch.
+// The values may be nil if every item failed.
+type BatchProcessFunc[T any, U any] func(context.Context, []T) ([]U, error)
+
+// BatchError reports the errors of individual items of a batch.
+type BatchError struct {
+	Errs []error // one error per item, in the order of the batch, nil for items that succeeded
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d of %d batch items failed", len(e.Unwrap()), len(e.Errs))
+}
+
+// Unwrap returns the errors of the failed items.
+func (e *BatchError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errs {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// BatchWorkerPool is a handle to a running pool of workers that process jobs in batches.
+type BatchWorkerPool[T any, U any] struct {
+	pool *WorkerPool[[]Job[T], []Result[T, U]]
+
+	mu       sync.Mutex
+	leftover []Job[T] // jobs collected into a batch that was never handed to the pool
+
+	batched chan struct{} // closed after the batcher exits
+	closed  chan struct{} // closed after the results channel is closed
+}
+
+// CreateBatchWorkerPool creates a pool of workers that groups jobs into batches of up to batchSize jobs,
+// or fewer if batchTimeout passes after the first job of a batch arrived, and processes each batch with a single call.
+// The result of every item is sent as an individual Result.
+// Options apply to whole batches: a failed batch is retried as a whole unless it failed with a BatchError.
+// WithErrorMode is the exception, it records the errors of individual jobs.
+func CreateBatchWorkerPool[T any, U any](ctx context.Context, numWorkers int, batchSize int, batchTimeout time.Duration, jobs <-chan Job[T], results chan<- Result[T, U], process BatchProcessFunc[T, U], opts ...Option) *BatchWorkerPool[T, U] {
+	batches := make(chan Job[[]Job[T]])
+	batchResults := make(chan Result[[]Job[T], []Result[T, U]])
+
+	// Errors are recorded per item rather than per batch, the pool of batches only sees the derived context.
+	cfg := newConfig(opts)
+	ctx, errs := errmode.New(ctx, cfg.errorMode, cfg.errorReport)
+	opts = append(slices.Clip(opts), WithErrorMode(ContinueOnError, nil))
+
+	b := &BatchWorkerPool[T, U]{
+		pool:    CreateWorkerPool(ctx, numWorkers, batches, batchResults, processBatch(process), opts...),
+		batched: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	go b.batch(ctx, max(batchSize, 1), batchTimeout, jobs, batches)
+
+	go func() {
+		defer close(b.closed)
+		defer close(results)
+		defer errs.Finish()
+
+		for result := range batchResults {
+			for _, itemResult := range splitBatchResult(result) {
+				errs.Record(itemResult.Job.ID, itemResult.Err)
+				results <- itemResult
+			}
+		}
+	}()
+
+	return b
+}
+
+// Resize changes the number of workers to n.
+func (b *BatchWorkerPool[T, U]) Resize(n int) {
+	b.pool.Resize(n)
+}
+
+// Size returns the current number of workers.
+func (b *BatchWorkerPool[T, U]) Size() int {
+	return b.pool.Size()
+}
+
+// Stop stops all workers once their in-flight batches are done,
+// and returns the jobs taken by the pool that were never processed.
+func (b *BatchWorkerPool[T, U]) Stop() []Job[T] {
+	var leftover []Job[T]
+	for _, batch := range b.pool.Stop() {
+		leftover = append(leftover, batch.Value...)
+	}
+	<-b.batched
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	leftover = append(leftover, b.leftover...)
+	b.leftover = nil
+	return leftover
+}
+
+// Wait blocks until all workers have exited and the results channel is closed.
+func (b *BatchWorkerPool[T, U]) Wait() {
+	<-b.closed
+}
+
+// batch collects jobs into batches and sends them to the pool.
+func (b *BatchWorkerPool[T, U]) batch(ctx context.Context, batchSize int, batchTimeout time.Duration, jobs <-chan Job[T], batches chan<- Job[[]Job[T]]) {
+	defer close(b.batched)
+	defer close(batches)
+
+	var (
+		current []Job[T]
+		timeout <-chan time.Time // nil until the first job of a batch arrives
+		timer   *time.Timer
+		id      int
+	)
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	// flush sends the current batch, it returns false if the pool stopped first.
+	flush := func() bool {
+		if timer != nil {
+			timer.Stop()
+			timer, timeout = nil, nil
+		}
+		if len(current) == 0 {
+			return true
+		}
+
+		id++
+		select {
+		case <-ctx.Done():
+		case <-b.pool.done:
+		case batches <- Job[[]Job[T]]{ID: id, Value: current}:
+			current = nil
+			return true
+		}
+		b.keepLeftover(current)
+		return false
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.keepLeftover(current)
+			return
+		case <-b.pool.done:
+			b.keepLeftover(current)
+			return
+		case <-timeout:
+			if !flush() {
+				return
+			}
+		case job, ok := <-jobs:
+			if !ok {
+				flush() // jobs channel closed, send the last partial batch
+				return
+			}
+			current = append(current, job)
+			if len(current) == 1 && batchTimeout > 0 {
+				timer = time.NewTimer(batchTimeout)
+				timeout = timer.C
+			}
+			if len(current) >= batchSize && !flush() {
+				return
+			}
+		}
+	}
+}
+
+func (b *BatchWorkerPool[T, U]) keepLeftover(jobs []Job[T]) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.leftover = append(b.leftover, jobs...)
+}
+
+// processBatch adapts a BatchProcessFunc to process a batch of jobs in a worker pool.
+func processBatch[T any, U any](process BatchProcessFunc[T, U]) ProcessFunc[[]Job[T], []Result[T, U]] {
+	return func(ctx context.Context, batch []Job[T]) ([]Result[T, U], error) {
+		values := make([]T, len(batch))
+		for i, job := range batch {
+			values[i] = job.Value
+		}
+
+		out, err := process(ctx, values)
+		var batchErr *BatchError
+		switch {
+		case errors.As(err, &batchErr) && len(batchErr.Errs) != len(batch):
+			return nil, ErrBatchSize
+		case err != nil && batchErr == nil:
+			return nil, err // the whole batch failed, it may be retried
+		case len(out) == 0 && batchErr != nil && len(batchErr.Unwrap()) == len(batch):
+			// Every item failed, there are no values to return.
+		case len(out) != len(batch):
+			return nil, ErrBatchSize
+		}
+
+		results := make([]Result[T, U], len(batch))
+		for i, job := range batch {
+			results[i] = Result[T, U]{Job: job}
+			if len(out) > 0 {
+				results[i].Value = out[i]
+			}
+			if batchErr != nil {
+				results[i].Err = batchErr.Errs[i]
+			}
+		}
+		return results, nil
+	}
+}
+
+// splitBatchResult turns the result of a batch into the results of its items.
+func splitBatchResult[T any, U any](result Result[[]Job[T], []Result[T, U]]) []Result[T, U] {
+	results := make([]Result[T, U], len(result.Job.Value))
+	for i, job := range result.Job.Value {
+		itemResult := Result[T, U]{Job: job, Err: result.Err}
+		if result.Err == nil {
+			itemResult = result.Value[i]
+		}
+
+		itemResult.Attempts = result.Attempts
+		itemResult.Errors = append([]error(nil), result.Errors...)
+		if result.Err == nil && itemResult.Err != nil {
+			itemResult.Errors = append(itemResult.Errors, itemResult.Err)
+		}
+		results[i] = itemResult
+	}
+	return results
+}
@@ -0,0 +1,225 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchWorkerPool(t *testing.T) {
+	errOdd := errors.New("odd value")
+	errBackend := errors.New("backend unavailable")
+
+	tests := []struct {
+		name        string
+		numJobs     int
+		batchSize   int
+		process     BatchProcessFunc[int, int]
+		wantBatches []int // sizes of the processed batches
+		wantErrs    map[int]error
+	}{
+		{
+			name:      "Batches of up to batch size",
+			numJobs:   7,
+			batchSize: 3,
+			process: func(_ context.Context, values []int) ([]int, error) {
+				out := make([]int, len(values))
+				for i, v := range values {
+					out[i] = v * 2
+				}
+				return out, nil
+			},
+			wantBatches: []int{3, 3, 1},
+			wantErrs:    map[int]error{},
+		},
+		{
+			name:      "Per-item errors",
+			numJobs:   4,
+			batchSize: 4,
+			process: func(_ context.Context, values []int) ([]int, error) {
+				out := make([]int, len(values))
+				errs := make([]error, len(values))
+				for i, v := range values {
+					out[i] = v * 2
+					if v%2 == 1 {
+						errs[i] = errOdd
+					}
+				}
+				return out, &BatchError{Errs: errs}
+			},
+			wantBatches: []int{4},
+			wantErrs:    map[int]error{1: errOdd, 3: errOdd},
+		},
+		{
+			name:      "Every item fails",
+			numJobs:   3,
+			batchSize: 3,
+			process: func(_ context.Context, values []int) ([]int, error) {
+				errs := make([]error, len(values))
+				for i := range values {
+					errs[i] = errOdd
+				}
+				return nil, &BatchError{Errs: errs}
+			},
+			wantBatches: []int{3},
+			wantErrs:    map[int]error{0: errOdd, 1: errOdd, 2: errOdd},
+		},
+		{
+			name:      "Per-item errors without values",
+			numJobs:   2,
+			batchSize: 2,
+			process: func(_ context.Context, values []int) ([]int, error) {
+				return nil, &BatchError{Errs: []error{errOdd, nil}}
+			},
+			wantBatches: []int{2},
+			wantErrs:    map[int]error{0: ErrBatchSize, 1: ErrBatchSize},
+		},
+		{
+			name:      "Whole batch fails",
+			numJobs:   2,
+			batchSize: 2,
+			process: func(_ context.Context, values []int) ([]int, error) {
+				return nil, errBackend
+			},
+			wantBatches: []int{2},
+			wantErrs:    map[int]error{0: errBackend, 1: errBackend},
+		},
+		{
+			name:      "Wrong number of values",
+			numJobs:   2,
+			batchSize: 2,
+			process: func(_ context.Context, values []int) ([]int, error) {
+				return values[:1], nil
+			},
+			wantBatches: []int{2},
+			wantErrs:    map[int]error{0: ErrBatchSize, 1: ErrBatchSize},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			var (
+				mu      sync.Mutex
+				batches []int
+			)
+			process := func(ctx context.Context, values []int) ([]int, error) {
+				mu.Lock()
+				batches = append(batches, len(values))
+				mu.Unlock()
+				return tt.process(ctx, values)
+			}
+
+			jobsChan := make(chan Job[int], tt.numJobs)
+			resultsChan := make(chan Result[int, int], tt.numJobs)
+			CreateBatchWorkerPool(ctx, 1, tt.batchSize, time.Minute, jobsChan, resultsChan, process)
+
+			for i := 0; i < tt.numJobs; i++ {
+				jobsChan <- Job[int]{ID: i, Value: i}
+			}
+			close(jobsChan)
+
+			var got []Result[int, int]
+			for result := range resultsChan {
+				got = append(got, result)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i].Job.ID < got[j].Job.ID })
+
+			assert.Equal(t, tt.wantBatches, batches)
+			assert.Len(t, got, tt.numJobs)
+			for _, result := range got {
+				assert.ErrorIs(t, result.Err, tt.wantErrs[result.Job.ID], "job %d", result.Job.ID)
+				if tt.wantErrs[result.Job.ID] == nil {
+					assert.NoError(t, result.Err)
+					assert.Equal(t, result.Job.Value*2, result.Value)
+				}
+				assert.Equal(t, 1, result.Attempts)
+			}
+		})
+	}
+}
+
+func TestBatchWorkerPoolTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	jobsChan := make(chan Job[int])
+	resultsChan := make(chan Result[int, int])
+	process := func(_ context.Context, values []int) ([]int, error) {
+		return values, nil
+	}
+	CreateBatchWorkerPool(ctx, 1, 100, 20*time.Millisecond, jobsChan, resultsChan, process)
+
+	jobsChan <- Job[int]{ID: 1, Value: 1}
+
+	// The batch is not full, it is flushed once the batch timeout passes.
+	select {
+	case result := <-resultsChan:
+		assert.Equal(t, 1, result.Value)
+	case <-time.After(time.Second):
+		t.Fatal("partial batch was not flushed after the batch timeout")
+	}
+	close(jobsChan)
+}
+
+func TestBatchWorkerPoolRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	errBackend := errors.New("backend unavailable")
+	calls := 0
+	process := func(_ context.Context, values []int) ([]int, error) {
+		calls++
+		if calls == 1 {
+			return nil, errBackend
+		}
+		return values, nil
+	}
+
+	jobsChan := make(chan Job[int], 2)
+	resultsChan := make(chan Result[int, int], 2)
+	CreateBatchWorkerPool(ctx, 1, 2, time.Minute, jobsChan, resultsChan, process, WithRetryPolicy(RetryPolicy{MaxAttempts: 2}))
+
+	jobsChan <- Job[int]{ID: 1, Value: 1}
+	jobsChan <- Job[int]{ID: 2, Value: 2}
+	close(jobsChan)
+
+	for result := range resultsChan {
+		assert.NoError(t, result.Err)
+		assert.Equal(t, result.Job.Value, result.Value)
+		assert.Equal(t, 2, result.Attempts)
+		assert.Equal(t, []error{errBackend}, result.Errors)
+	}
+	assert.Equal(t, 2, calls)
+}
+
+func TestBatchWorkerPoolStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	jobsChan := make(chan Job[int], 3)
+	resultsChan := make(chan Result[int, int], 3)
+	process := func(_ context.Context, values []int) ([]int, error) {
+		return values, nil
+	}
+	pool := CreateBatchWorkerPool(ctx, 1, 10, time.Minute, jobsChan, resultsChan, process)
+
+	for i := 1; i <= 3; i++ {
+		jobsChan <- Job[int]{ID: i, Value: i}
+	}
+	assert.Eventually(t, func() bool { return len(jobsChan) == 0 }, time.Second, time.Millisecond)
+
+	// The partial batch was never handed to a worker, its jobs are returned.
+	leftover := pool.Stop()
+	pool.Wait()
+	assert.Equal(t, []Job[int]{{ID: 1, Value: 1}, {ID: 2, Value: 2}, {ID: 3, Value: 3}}, leftover)
+	_, ok := <-resultsChan
+	assert.False(t, ok)
+}
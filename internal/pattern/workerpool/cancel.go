@@ -0,0 +1,169 @@
+package workerpool
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/errmode"
+)
+
+// ErrJobCancelled is the error of a job cancelled by ID.
+var ErrJobCancelled = errors.New("job cancelled")
+
+// maxEarlyCancels is how many cancellations of jobs the pool has not received yet are remembered.
+const maxEarlyCancels = 1024
+
+// cancels tracks the jobs of a pool that are cancelled by ID.
+// Cancellations of jobs the pool has not received yet, such as jobs still buffered in the jobs channel,
+// are remembered until the job arrives, up to maxEarlyCancels of them, the oldest are forgotten first.
+// The zero value is ready to use.
+type cancels struct {
+	mu       sync.Mutex
+	accepted map[int]int           // number of accepted jobs that have not started yet, by ID
+	pending  map[int]struct{}      // accepted jobs cancelled before they started
+	running  map[int]*runningJob   // running jobs, by ID
+	early    *list.List            // IDs cancelled before the pool received them, oldest first
+	earlyIDs map[int]*list.Element // elements of the early list by ID
+}
+
+// runningJob is a job that is running, and can be cancelled by ID.
+type runningJob struct {
+	cancel    context.CancelCauseFunc
+	cancelled bool // set once the job is cancelled by ID, guarded by the mutex of cancels
+}
+
+// accept registers a job taken by the pool, so it can be cancelled before it starts.
+func (c *cancels) accept(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accepted == nil {
+		c.accepted = make(map[int]int)
+	}
+	c.accepted[id]++
+
+	if elem, ok := c.earlyIDs[id]; ok {
+		c.early.Remove(elem)
+		delete(c.earlyIDs, id)
+		c.markPending(id)
+	}
+}
+
+// forget unregisters an accepted job that will never start, along with its cancellation.
+func (c *cancels) forget(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.unaccept(id)
+}
+
+// unaccept unregisters an accepted job, and reports whether it was cancelled. The mutex must be held.
+func (c *cancels) unaccept(id int) bool {
+	_, cancelled := c.pending[id]
+	delete(c.pending, id)
+	if c.accepted[id] <= 1 {
+		delete(c.accepted, id)
+	} else {
+		c.accepted[id]--
+	}
+	return cancelled
+}
+
+// cancel cancels the running job with the given ID, or marks it to be skipped when it starts.
+// If the pool has not received the job yet, it is marked once it arrives.
+func (c *cancels) cancel(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if job, ok := c.running[id]; ok {
+		job.cancelled = true
+		job.cancel(ErrJobCancelled)
+		return
+	}
+	if c.accepted[id] > 0 {
+		c.markPending(id)
+		return
+	}
+	c.remember(id)
+}
+
+// markPending marks an accepted job to be skipped when it starts. The mutex must be held.
+func (c *cancels) markPending(id int) {
+	if c.pending == nil {
+		c.pending = make(map[int]struct{})
+	}
+	c.pending[id] = struct{}{}
+}
+
+// remember keeps the cancellation of a job the pool has not received yet, forgetting the oldest one if there are
+// too many. The mutex must be held.
+func (c *cancels) remember(id int) {
+	if _, ok := c.earlyIDs[id]; ok {
+		return
+	}
+	if c.early == nil {
+		c.early = list.New()
+		c.earlyIDs = make(map[int]*list.Element)
+	}
+	if c.early.Len() >= maxEarlyCancels {
+		oldest := c.early.Front()
+		c.early.Remove(oldest)
+		delete(c.earlyIDs, oldest.Value.(int))
+	}
+	c.earlyIDs[id] = c.early.PushBack(id)
+}
+
+// begin registers a job as running and returns its context, along with a function to call once the job is done,
+// which reports whether the job was cancelled by ID while it was running.
+// It returns false if the job was cancelled before it started.
+func (c *cancels) begin(ctx context.Context, id int) (context.Context, func() bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.unaccept(id) {
+		return nil, nil, false
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	if c.running == nil {
+		c.running = make(map[int]*runningJob)
+	}
+	job := &runningJob{cancel: cancel}
+	c.running[id] = job
+
+	return ctx, func() bool {
+		c.mu.Lock()
+		delete(c.running, id)
+		cancelled := job.cancelled
+		c.mu.Unlock()
+		cancel(nil)
+		return cancelled
+	}, true
+}
+
+// runUnlessCancelled runs a task unless its job was cancelled before it started.
+// A job cancelled before it started, or cancelled while running without succeeding, is reported with ErrJobCancelled.
+// Only a cancellation of the job itself counts, not one of the pool's context whose cause happens to wrap ErrJobCancelled.
+func (r runner[T, U]) runUnlessCancelled(ctx context.Context, c *cancels, t task[T]) Result[T, U] {
+	ctx, done, ok := c.begin(ctx, t.job.ID)
+	if !ok {
+		return Result[T, U]{Job: t.job, Err: ErrJobCancelled}
+	}
+
+	result := r.run(ctx, t)
+	if done() && result.Err != nil {
+		result.Err = ErrJobCancelled
+	}
+	return result
+}
+
+// recordUnlessCancelled records the error of a job with the error mode of the pool.
+// Jobs cancelled by ID are left out, cancelling one job must not fail the other jobs.
+func recordUnlessCancelled(errs *errmode.Collector, id int, err error) {
+	if errors.Is(err, ErrJobCancelled) {
+		return
+	}
+	errs.Record(id, err)
+}
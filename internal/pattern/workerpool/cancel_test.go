@@ -0,0 +1,182 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkerPoolCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	started := make(chan int, 3)
+	var processed atomic.Int32
+	process := func(ctx context.Context, value int) (int, error) {
+		processed.Add(1)
+		started <- value
+		if value == 1 {
+			<-ctx.Done() // runs until cancelled
+			return 0, ctx.Err()
+		}
+		return value, nil
+	}
+
+	jobsChan := make(chan Job[int], 3)
+	resultsChan := make(chan Result[int, int], 3)
+	retry := WithRetryPolicy(RetryPolicy{MaxAttempts: 3})
+	pool := CreateWorkerPool(ctx, 1, jobsChan, resultsChan, process, retry)
+
+	for i := 1; i <= 3; i++ {
+		jobsChan <- Job[int]{ID: i, Value: i}
+	}
+	close(jobsChan)
+
+	assert.Equal(t, 1, <-started)
+	pool.Cancel(2) // queued behind the running job, skipped
+	pool.Cancel(1) // running, its context is cancelled
+
+	got := map[int]Result[int, int]{}
+	for result := range resultsChan {
+		got[result.Job.ID] = result
+	}
+
+	assert.ErrorIs(t, got[1].Err, ErrJobCancelled)
+	assert.Equal(t, 1, got[1].Attempts) // not retried once cancelled
+	assert.ErrorIs(t, got[2].Err, ErrJobCancelled)
+	assert.Equal(t, 0, got[2].Attempts)
+	assert.NoError(t, got[3].Err)
+	assert.Equal(t, 3, got[3].Value)
+	assert.Equal(t, int32(2), processed.Load())
+}
+
+func TestWorkStealingPoolCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	errStopped := errors.New("stopped")
+	started := make(chan struct{})
+	process := func(ctx context.Context, value int) (int, error) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			return 0, errStopped
+		case <-time.After(time.Second):
+			return value, nil
+		}
+	}
+
+	jobsChan := make(chan Job[int], 1)
+	resultsChan := make(chan Result[int, int], 1)
+	pool := CreateWorkStealingPool(ctx, 2, jobsChan, resultsChan, process)
+
+	jobsChan <- Job[int]{ID: 7, Value: 7}
+	close(jobsChan)
+	<-started
+	pool.Cancel(7)
+
+	result := <-resultsChan
+	assert.ErrorIs(t, result.Err, ErrJobCancelled)
+	assert.Equal(t, []error{errStopped}, result.Errors)
+}
+
+func TestCancelWithFailFast(t *testing.T) {
+	tests := []struct {
+		name   string
+		create func(ctx context.Context, jobs <-chan Job[int], results chan<- Result[int, int], process ProcessFunc[int, int], opts ...Option) interface{ Cancel(int) }
+	}{
+		{
+			name: "Worker pool",
+			create: func(ctx context.Context, jobs <-chan Job[int], results chan<- Result[int, int], process ProcessFunc[int, int], opts ...Option) interface{ Cancel(int) } {
+				return CreateWorkerPool(ctx, 2, jobs, results, process, opts...)
+			},
+		},
+		{
+			name: "Work-stealing pool",
+			create: func(ctx context.Context, jobs <-chan Job[int], results chan<- Result[int, int], process ProcessFunc[int, int], opts ...Option) interface{ Cancel(int) } {
+				return CreateWorkStealingPool(ctx, 2, jobs, results, process, opts...)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			started := make(chan int, 2)
+			release := make(chan struct{})
+			process := func(ctx context.Context, value int) (int, error) {
+				started <- value
+				select {
+				case <-ctx.Done():
+					return 0, ctx.Err()
+				case <-release:
+					return value, nil
+				}
+			}
+
+			jobsChan := make(chan Job[int], 2)
+			resultsChan := make(chan Result[int, int], 2)
+			var report ErrorReport
+			pool := tt.create(ctx, jobsChan, resultsChan, process, WithErrorMode(FailFast, &report))
+
+			jobsChan <- Job[int]{ID: 1, Value: 1}
+			jobsChan <- Job[int]{ID: 2, Value: 2}
+			close(jobsChan)
+			<-started
+			<-started
+
+			pool.Cancel(1) // must not fail the other job
+			assert.ErrorIs(t, (<-resultsChan).Err, ErrJobCancelled)
+			close(release)
+
+			got := <-resultsChan
+			assert.NoError(t, got.Err)
+			assert.Equal(t, 2, got.Value)
+			_, open := <-resultsChan
+			assert.False(t, open)
+			assert.NoError(t, report.Err())
+		})
+	}
+}
+
+func TestCancelBufferedJobs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	numJobs := maxEarlyCancels + 2
+	jobsChan := make(chan Job[int], numJobs)
+	resultsChan := make(chan Result[int, int], numJobs)
+
+	pool := CreateWorkerPool(ctx, 1, jobsChan, resultsChan, func(_ context.Context, value int) (int, error) {
+		return value, nil
+	})
+
+	// Every job is cancelled before the pool receives it, only the most recent maxEarlyCancels are remembered.
+	for i := 0; i < numJobs; i++ {
+		pool.Cancel(i)
+	}
+	for i := 0; i < numJobs; i++ {
+		jobsChan <- Job[int]{ID: i, Value: i}
+	}
+	close(jobsChan)
+
+	cancelled := 0
+	for result := range resultsChan {
+		switch result.Job.ID {
+		case 0, 1:
+			assert.NoError(t, result.Err, "job %d", result.Job.ID)
+		default:
+			assert.ErrorIs(t, result.Err, ErrJobCancelled, "job %d", result.Job.ID)
+			cancelled++
+		}
+	}
+	assert.Equal(t, maxEarlyCancels, cancelled)
+	assert.Empty(t, pool.cancels.earlyIDs)
+	assert.Empty(t, pool.cancels.pending)
+}
@@ -0,0 +1,294 @@
+// Package dag runs graphs of jobs where jobs consume the outputs of the jobs they depend on.
+package dag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/workerpool"
+)
+
+var (
+	// ErrCycle is returned when the dependencies of the jobs form a cycle.
+	ErrCycle = errors.New("dag: dependency cycle")
+	// ErrUnknownDependency is returned when a job depends on a job that is not in the graph.
+	ErrUnknownDependency = errors.New("dag: unknown dependency")
+	// ErrDuplicateID is returned when two jobs in the graph have the same ID.
+	ErrDuplicateID = errors.New("dag: duplicate job ID")
+	// ErrSkipped is matched by the errors of skipped jobs.
+	ErrSkipped = errors.New("dag: job skipped")
+	// ErrStopped is the cause of jobs skipped because the worker pool stopped for no other known reason.
+	ErrStopped = errors.New("dag: worker pool stopped")
+)
+
+// Node is a job in a graph, along with the IDs of the jobs it depends on.
+type Node[T any] struct {
+	Job       workerpool.Job[T]
+	DependsOn []int
+}
+
+// ProcessFunc processes the value of a job, given the outputs of the jobs it depends on, keyed by job ID.
+type ProcessFunc[T any, U any] func(ctx context.Context, value T, parents map[int]U) (U, error)
+
+// Status is the final state of a job in a graph.
+type Status int
+
+const (
+	Succeeded Status = iota + 1 // the job was processed without error
+	Failed                      // the job was processed and returned an error
+	Skipped                     // the job was never processed, its error is a SkipError
+)
+
+func (s Status) String() string {
+	switch s {
+	case Succeeded:
+		return "succeeded"
+	case Failed:
+		return "failed"
+	case Skipped:
+		return "skipped"
+	default:
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+}
+
+// SkipError is the error of a job that was skipped, because a job it depends on did not succeed,
+// or because the context was cancelled, or the worker pool stopped, before it could run.
+type SkipError struct {
+	Dependency int   // ID of the dependency that did not succeed, -1 if the job never got to run
+	Cause      error // error of the dependency, or why the job never got to run, never nil
+}
+
+func (e *SkipError) Error() string {
+	if e.Dependency < 0 {
+		return fmt.Sprintf("skipped: %v", e.Cause)
+	}
+	return fmt.Sprintf("skipped: dependency %d did not succeed: %v", e.Dependency, e.Cause)
+}
+
+func (e *SkipError) Is(target error) bool {
+	return target == ErrSkipped
+}
+
+func (e *SkipError) Unwrap() error {
+	return e.Cause
+}
+
+// NodeResult is the final state of a job in a graph.
+type NodeResult[T any, U any] struct {
+	workerpool.Result[T, U]
+	Status Status
+}
+
+// input is what a job in the worker pool processes, the job's value along with the outputs of its dependencies.
+type input[T any, U any] struct {
+	value   T
+	parents map[int]U
+}
+
+// graph is the dependency structure of the jobs, keyed by job ID.
+type graph[T any] struct {
+	nodes    map[int]Node[T]
+	children map[int][]int
+}
+
+// Run runs the jobs of a graph with up to numWorkers jobs at a time, each as soon as all its dependencies succeeded.
+// The descendants of a job that fails are skipped, as are the jobs that did not start before the context was cancelled
+// or the worker pool stopped, such as after the first failure with workerpool.FailFast.
+// The graph is validated before any job runs, a cycle, an unknown dependency or a duplicate ID is returned as an error.
+// Options apply to the worker pool that runs the jobs, for example to retry failed jobs before their descendants are skipped.
+// It returns the final state of every job, keyed by job ID.
+func Run[T any, U any](ctx context.Context, numWorkers int, nodes []Node[T], process ProcessFunc[T, U], opts ...workerpool.Option) (map[int]NodeResult[T, U], error) {
+	g, err := newGraph(nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // stop the worker pool if Run returns early
+
+	jobs := make(chan workerpool.Job[input[T, U]])
+	results := make(chan workerpool.Result[input[T, U], U])
+	workerpool.CreateWorkerPool(ctx, max(numWorkers, 1), jobs, results, func(ctx context.Context, in input[T, U]) (U, error) {
+		return process(ctx, in.value, in.parents)
+	}, opts...)
+
+	state := make(map[int]NodeResult[T, U], len(nodes))
+	waiting := make(map[int]int, len(nodes)) // number of dependencies not succeeded yet
+	var ready []int
+	var firstErr error // error of the first job that failed
+	for _, node := range nodes {
+		waiting[node.Job.ID] = len(node.DependsOn)
+		if len(node.DependsOn) == 0 {
+			ready = append(ready, node.Job.ID)
+		}
+	}
+
+	// skip marks the not yet finished descendants of a job as skipped.
+	var skip func(id int, reason *SkipError)
+	skip = func(id int, reason *SkipError) {
+		for _, child := range g.children[id] {
+			if _, done := state[child]; done {
+				continue
+			}
+			state[child] = NodeResult[T, U]{
+				Result: workerpool.Result[T, U]{Job: g.nodes[child].Job, Err: reason},
+				Status: Skipped,
+			}
+			skip(child, reason)
+		}
+	}
+
+	for len(state) < len(nodes) {
+		var send chan<- workerpool.Job[input[T, U]]
+		var next workerpool.Job[input[T, U]]
+		if len(ready) > 0 {
+			send, next = jobs, newJob(g, ready[0], state)
+		}
+
+		select {
+		case send <- next:
+			ready = ready[1:]
+		case result, ok := <-results:
+			if !ok {
+				// The context was cancelled, or the worker pool stopped, such as on a failure with FailFast.
+				// The jobs that never ran are skipped.
+				cause := context.Cause(ctx)
+				if cause == nil {
+					cause = firstErr
+				}
+				if cause == nil {
+					cause = ErrStopped
+				}
+				for id, node := range g.nodes {
+					if _, done := state[id]; !done {
+						state[id] = NodeResult[T, U]{
+							Result: workerpool.Result[T, U]{Job: node.Job, Err: &SkipError{Dependency: -1, Cause: cause}},
+							Status: Skipped,
+						}
+					}
+				}
+				return state, nil
+			}
+			id := result.Job.ID
+			nodeResult := NodeResult[T, U]{
+				Result: workerpool.Result[T, U]{
+					Job:      g.nodes[id].Job,
+					Value:    result.Value,
+					Err:      result.Err,
+					Attempts: result.Attempts,
+					Errors:   result.Errors,
+				},
+				Status: Succeeded,
+			}
+			if result.Err != nil {
+				if firstErr == nil {
+					firstErr = result.Err
+				}
+				nodeResult.Status = Failed
+				state[id] = nodeResult
+				skip(id, &SkipError{Dependency: id, Cause: result.Err})
+				continue
+			}
+
+			state[id] = nodeResult
+			for _, child := range g.children[id] {
+				waiting[child]--
+				if _, done := state[child]; !done && waiting[child] == 0 {
+					ready = append(ready, child)
+				}
+			}
+		}
+	}
+	close(jobs)
+
+	for range results {
+		// wait for the worker pool to exit
+	}
+	return state, nil
+}
+
+// newGraph validates the jobs and their dependencies.
+func newGraph[T any](nodes []Node[T]) (*graph[T], error) {
+	g := &graph[T]{
+		nodes:    make(map[int]Node[T], len(nodes)),
+		children: make(map[int][]int),
+	}
+	for _, node := range nodes {
+		if _, ok := g.nodes[node.Job.ID]; ok {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateID, node.Job.ID)
+		}
+		g.nodes[node.Job.ID] = node
+	}
+	for _, node := range nodes {
+		for _, parent := range node.DependsOn {
+			if _, ok := g.nodes[parent]; !ok {
+				return nil, fmt.Errorf("%w: job %d depends on %d", ErrUnknownDependency, node.Job.ID, parent)
+			}
+			g.children[parent] = append(g.children[parent], node.Job.ID)
+		}
+	}
+
+	if cycle := g.findCycle(); cycle != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCycle, cycle)
+	}
+	return g, nil
+}
+
+// findCycle returns the IDs of the jobs forming a dependency cycle, in dependency order, or nil if there is none.
+func (g *graph[T]) findCycle() []int {
+	const (
+		unvisited = iota
+		visiting  // on the current path
+		visited   // no cycle reachable
+	)
+	marks := make(map[int]int, len(g.nodes))
+	var path []int
+
+	var visit func(id int) []int
+	visit = func(id int) []int {
+		marks[id] = visiting
+		path = append(path, id)
+		for _, child := range g.children[id] {
+			switch marks[child] {
+			case visiting:
+				start := slices.Index(path, child)
+				return append(slices.Clone(path[start:]), child)
+			case unvisited:
+				if cycle := visit(child); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		marks[id] = visited
+		return nil
+	}
+
+	ids := make([]int, 0, len(g.nodes))
+	for id := range g.nodes {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids) // report the same cycle on every run
+
+	for _, id := range ids {
+		if marks[id] == unvisited {
+			if cycle := visit(id); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// newJob builds the worker pool job of a node, with the outputs of its dependencies.
+func newJob[T any, U any](g *graph[T], id int, state map[int]NodeResult[T, U]) workerpool.Job[input[T, U]] {
+	node := g.nodes[id]
+	parents := make(map[int]U, len(node.DependsOn))
+	for _, parent := range node.DependsOn {
+		parents[parent] = state[parent].Value
+	}
+	return workerpool.Job[input[T, U]]{ID: id, Value: input[T, U]{value: node.Job.Value, parents: parents}}
+}
@@ -0,0 +1,230 @@
+package dag
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/workerpool"
+)
+
+// node creates a node whose value is its ID.
+func node(id int, dependsOn ...int) Node[int] {
+	return Node[int]{Job: workerpool.Job[int]{ID: id, Value: id}, DependsOn: dependsOn}
+}
+
+// sum returns the value of a job plus the outputs of its dependencies, and fails for the value 13.
+func sum(_ context.Context, value int, parents map[int]int) (int, error) {
+	if value == 13 {
+		return 0, errors.New("unlucky")
+	}
+	for _, output := range parents {
+		value += output
+	}
+	return value, nil
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		nodes      []Node[int]
+		wantValues map[int]int
+		wantStatus map[int]Status
+		wantSkip   map[int]int // dependency reported by skipped jobs
+	}{
+		{
+			name:       "Diamond",
+			nodes:      []Node[int]{node(4, 2, 3), node(2, 1), node(3, 1), node(1)},
+			wantValues: map[int]int{1: 1, 2: 3, 3: 4, 4: 11},
+			wantStatus: map[int]Status{1: Succeeded, 2: Succeeded, 3: Succeeded, 4: Succeeded},
+			wantSkip:   map[int]int{},
+		},
+		{
+			name:       "Descendants of a failed job are skipped",
+			nodes:      []Node[int]{node(1), node(13, 1), node(3, 1), node(4, 13, 3), node(5, 4), node(6, 3)},
+			wantValues: map[int]int{1: 1, 3: 4, 6: 10},
+			wantStatus: map[int]Status{1: Succeeded, 13: Failed, 3: Succeeded, 4: Skipped, 5: Skipped, 6: Succeeded},
+			wantSkip:   map[int]int{4: 13, 5: 13},
+		},
+		{
+			name:       "Empty graph",
+			wantValues: map[int]int{},
+			wantStatus: map[int]Status{},
+			wantSkip:   map[int]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Run(context.Background(), 2, tt.nodes, sum)
+			require.NoError(t, err)
+
+			status := map[int]Status{}
+			for id, result := range got {
+				status[id] = result.Status
+				assert.Equal(t, id, result.Job.ID)
+
+				switch result.Status {
+				case Succeeded:
+					assert.Equal(t, tt.wantValues[id], result.Value, "job %d", id)
+				case Skipped:
+					var skipErr *SkipError
+					require.ErrorAs(t, result.Err, &skipErr)
+					assert.ErrorIs(t, result.Err, ErrSkipped)
+					assert.Equal(t, tt.wantSkip[id], skipErr.Dependency, "job %d", id)
+				}
+			}
+			assert.Equal(t, tt.wantStatus, status)
+		})
+	}
+}
+
+func TestRunInvalidGraph(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []Node[int]
+		wantErr error
+	}{
+		{
+			name:    "Cycle",
+			nodes:   []Node[int]{node(1), node(2, 1, 4), node(3, 2), node(4, 3)},
+			wantErr: ErrCycle,
+		},
+		{
+			name:    "Self dependency",
+			nodes:   []Node[int]{node(1, 1)},
+			wantErr: ErrCycle,
+		},
+		{
+			name:    "Unknown dependency",
+			nodes:   []Node[int]{node(1), node(2, 3)},
+			wantErr: ErrUnknownDependency,
+		},
+		{
+			name:    "Duplicate ID",
+			nodes:   []Node[int]{node(1), node(1)},
+			wantErr: ErrDuplicateID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processed := false
+			process := func(context.Context, int, map[int]int) (int, error) {
+				processed = true
+				return 0, nil
+			}
+
+			got, err := Run(context.Background(), 2, tt.nodes, process)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Nil(t, got)
+			assert.False(t, processed) // validated before running
+		})
+	}
+
+	_, err := Run(context.Background(), 1, []Node[int]{node(1), node(2, 1, 4), node(3, 2), node(4, 3)}, sum)
+	assert.EqualError(t, err, "dag: dependency cycle: [2 3 4 2]")
+}
+
+func TestRunBoundedConcurrency(t *testing.T) {
+	const numWorkers = 2
+
+	var (
+		mu      sync.Mutex
+		running int
+		peak    int
+	)
+	process := func(_ context.Context, value int, _ map[int]int) (int, error) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return value, nil
+	}
+
+	var nodes []Node[int]
+	for i := 0; i < 8; i++ {
+		nodes = append(nodes, node(i))
+	}
+	got, err := Run(context.Background(), numWorkers, nodes, process)
+	require.NoError(t, err)
+
+	assert.Len(t, got, len(nodes))
+	assert.Equal(t, numWorkers, peak)
+}
+
+func TestRunCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	process := func(ctx context.Context, value int, _ map[int]int) (int, error) {
+		if value == 1 {
+			cancel()
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return value, nil
+	}
+
+	got, err := Run(ctx, 1, []Node[int]{node(1), node(2, 1), node(3)}, process)
+	require.NoError(t, err)
+
+	assert.Len(t, got, 3)
+	assert.Equal(t, Failed, got[1].Status)
+	assert.Equal(t, Skipped, got[2].Status)
+	var skipErr *SkipError
+	require.ErrorAs(t, got[3].Err, &skipErr)
+	assert.Equal(t, -1, skipErr.Dependency)
+	assert.ErrorIs(t, got[3].Err, context.Canceled)
+}
+
+func TestRunFailFast(t *testing.T) {
+	errFailed := errors.New("failed")
+	started := make(chan struct{})
+	process := func(ctx context.Context, value int, _ map[int]int) (int, error) {
+		switch value {
+		case 1:
+			<-started
+			return 0, errFailed // stops the worker pool
+		case 2:
+			close(started)
+			<-ctx.Done()
+			return value, nil // succeeds despite the stop, its descendants never get to run
+		}
+		return value, nil
+	}
+
+	nodes := []Node[int]{node(1), node(2)}
+	for id := 3; id <= 10; id++ {
+		nodes = append(nodes, node(id, 2))
+	}
+	got, err := Run(context.Background(), 2, nodes, process, workerpool.WithErrorMode(workerpool.FailFast, nil))
+	require.NoError(t, err)
+
+	assert.Len(t, got, len(nodes))
+	assert.Equal(t, Failed, got[1].Status)
+	assert.Equal(t, Succeeded, got[2].Status)
+	skipped := 0
+	for id := 3; id <= 10; id++ {
+		if got[id].Status != Skipped {
+			continue // taken by the pool before it stopped
+		}
+		skipped++
+		var skipErr *SkipError
+		require.ErrorAs(t, got[id].Err, &skipErr)
+		assert.Equal(t, -1, skipErr.Dependency)
+		assert.ErrorIs(t, skipErr.Cause, errFailed)
+	}
+	assert.NotZero(t, skipped)
+}
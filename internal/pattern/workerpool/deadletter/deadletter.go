@@ -0,0 +1,187 @@
+// Package deadletter keeps the jobs that failed in a worker pool, so they can be inspected and reprocessed later.
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/workerpool"
+)
+
+// ErrNotFound is returned when there is no entry for a job ID.
+var ErrNotFound = errors.New("deadletter: entry not found")
+
+// Entry is a failed job with its error history.
+type Entry[T any] struct {
+	Job           workerpool.Job[T]
+	Errors        []string  // errors of all failed attempts, oldest first, across requeues
+	Attempts      int       // number of times the job was processed, across requeues
+	FirstFailedAt time.Time // when the job was first captured
+	LastFailedAt  time.Time // when the job was last captured
+	Requeues      int       // number of times the job was requeued
+	RequeuedAt    time.Time // when the job was last requeued, zero if never
+}
+
+// Queue is a dead-letter queue of failed jobs, backed by a Store.
+type Queue[T any] struct {
+	store Store[T]
+	now   func() time.Time
+
+	mu sync.Mutex // serialises updates of existing entries
+}
+
+// New creates a dead-letter queue backed by the given store.
+func New[T any](store Store[T]) *Queue[T] {
+	return &Queue[T]{store: store, now: time.Now}
+}
+
+// List returns all entries, ordered by job ID.
+func (q *Queue[T]) List() ([]Entry[T], error) {
+	return q.store.List()
+}
+
+// Inspect returns the entry of a job, ErrNotFound if the job is not in the queue.
+func (q *Queue[T]) Inspect(id int) (Entry[T], error) {
+	return q.store.Get(id)
+}
+
+// Requeue sends the jobs with the given IDs to the jobs channel, so they can be processed again.
+// The entries stay in the queue until Capture sees the jobs succeed, if they fail again their history is extended.
+// Requeue stops at the first job that is not in the queue, or when the context is cancelled.
+func (q *Queue[T]) Requeue(ctx context.Context, jobs chan<- workerpool.Job[T], ids ...int) error {
+	for _, id := range ids {
+		entry, err := q.store.Get(id)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case jobs <- entry.Job:
+		}
+
+		if err := q.update(id, func(entry *Entry[T]) {
+			entry.Requeues++
+			entry.RequeuedAt = q.now()
+		}); err != nil && !errors.Is(err, ErrNotFound) { // the job may have succeeded already
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge removes the entries of the given jobs for good.
+func (q *Queue[T]) Purge(ids ...int) error {
+	return q.store.Delete(ids...)
+}
+
+// add records a failed job, extending the history of its entry if it failed before.
+func (q *Queue[T]) add(job workerpool.Job[T], errs []error, attempts int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	entry, err := q.store.Get(job.ID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		entry = Entry[T]{FirstFailedAt: now}
+	case err != nil:
+		return err
+	}
+
+	entry.Job = job
+	for _, err := range errs {
+		entry.Errors = append(entry.Errors, err.Error())
+	}
+	entry.Attempts += attempts
+	entry.LastFailedAt = now
+	return q.store.Put(entry)
+}
+
+// resolve removes the entry of a job that succeeded, if there is one.
+func (q *Queue[T]) resolve(id int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.store.Delete(id)
+}
+
+// update changes the entry of a job.
+func (q *Queue[T]) update(id int, change func(*Entry[T])) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, err := q.store.Get(id)
+	if err != nil {
+		return err
+	}
+	change(&entry)
+	return q.store.Put(entry)
+}
+
+// Capture passes results through, adding the jobs of failed results to the dead-letter queue,
+// and removing the entries of requeued jobs that succeeded.
+// Results reach Capture once the pool's retry budget is used up, so every failed result is final.
+// Cancelled jobs are not failures: results whose error wraps workerpool.ErrJobCancelled or context.Canceled,
+// such as jobs cancelled by ID, or by the pool shutting down, pass through without touching the queue.
+// If the queue cannot store a failed job, the error is joined to the result's error.
+// The returned channel is closed once the results channel is closed or the context is cancelled.
+func Capture[T any, U any](ctx context.Context, q *Queue[T], results <-chan workerpool.Result[T, U]) <-chan workerpool.Result[T, U] {
+	out := make(chan workerpool.Result[T, U])
+
+	go func() {
+		defer close(out)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case result, ok := <-results:
+				if !ok {
+					return
+				}
+				if err := capture(q, result); err != nil {
+					result.Err = errors.Join(result.Err, err)
+				}
+
+				select {
+				case <-ctx.Done():
+					return
+				case out <- result:
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+func capture[T any, U any](q *Queue[T], result workerpool.Result[T, U]) error {
+	if result.Err == nil {
+		if err := q.resolve(result.Job.ID); err != nil {
+			return fmt.Errorf("deadletter: resolve job %d: %w", result.Job.ID, err)
+		}
+		return nil
+	}
+	if cancelled(result.Err) {
+		return nil
+	}
+
+	errs := result.Errors
+	if len(errs) == 0 { // the job was never attempted, for example because its deadline passed
+		errs = []error{result.Err}
+	}
+	if err := q.add(result.Job, errs, result.Attempts); err != nil {
+		return fmt.Errorf("deadletter: add job %d: %w", result.Job.ID, err)
+	}
+	return nil
+}
+
+// cancelled reports whether an error is the cancellation of a job rather than its failure.
+func cancelled(err error) bool {
+	return errors.Is(err, workerpool.ErrJobCancelled) || errors.Is(err, context.Canceled)
+}
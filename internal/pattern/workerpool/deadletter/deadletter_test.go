@@ -0,0 +1,150 @@
+package deadletter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/workerpool"
+)
+
+func TestCapture(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	q := New[int](NewMemoryStore[int]())
+	q.now = func() time.Time { return now }
+
+	broken := true
+	process := func(_ context.Context, value int) (int, error) {
+		if value%2 == 1 && broken {
+			return 0, errors.New("odd value")
+		}
+		return value, nil
+	}
+
+	jobsChan := make(chan workerpool.Job[int])
+	resultsChan := make(chan workerpool.Result[int, int])
+	retry := workerpool.WithRetryPolicy(workerpool.RetryPolicy{MaxAttempts: 2})
+	workerpool.CreateWorkerPool(ctx, 1, jobsChan, resultsChan, process, retry)
+	results := Capture(ctx, q, resultsChan)
+
+	// All results pass through, the failed jobs are kept.
+	for i := 0; i < 4; i++ {
+		jobsChan <- workerpool.Job[int]{ID: i, Value: i}
+		<-results
+	}
+
+	entries, err := q.List()
+	require.NoError(t, err)
+	assert.Equal(t, []Entry[int]{
+		{Job: workerpool.Job[int]{ID: 1, Value: 1}, Errors: []string{"odd value", "odd value"}, Attempts: 2, FirstFailedAt: now, LastFailedAt: now},
+		{Job: workerpool.Job[int]{ID: 3, Value: 3}, Errors: []string{"odd value", "odd value"}, Attempts: 2, FirstFailedAt: now, LastFailedAt: now},
+	}, entries)
+
+	// A requeued job that fails again extends its history.
+	later := now.Add(time.Hour)
+	q.now = func() time.Time { return later }
+	require.NoError(t, q.Requeue(ctx, jobsChan, 1))
+	<-results
+
+	entry, err := q.Inspect(1)
+	require.NoError(t, err)
+	assert.Equal(t, Entry[int]{
+		Job:           workerpool.Job[int]{ID: 1, Value: 1},
+		Errors:        []string{"odd value", "odd value", "odd value", "odd value"},
+		Attempts:      4,
+		FirstFailedAt: now,
+		LastFailedAt:  later,
+		Requeues:      1,
+		RequeuedAt:    later,
+	}, entry)
+
+	// Once fixed, a requeued job that succeeds leaves the queue.
+	broken = false
+	require.NoError(t, q.Requeue(ctx, jobsChan, 1))
+	result := <-results
+	assert.NoError(t, result.Err)
+
+	_, err = q.Inspect(1)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, q.Requeue(ctx, jobsChan, 1), ErrNotFound)
+
+	require.NoError(t, q.Purge(3))
+	entries, err = q.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	close(jobsChan)
+	_, ok := <-results
+	assert.False(t, ok)
+}
+
+func TestCaptureStoreError(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	errStore := errors.New("disk full")
+	errJob := errors.New("job failed")
+	q := New[int](failingStore{Store: NewMemoryStore[int](), err: errStore})
+
+	resultsChan := make(chan workerpool.Result[int, int], 1)
+	resultsChan <- workerpool.Result[int, int]{Job: workerpool.Job[int]{ID: 1}, Err: errJob}
+	close(resultsChan)
+
+	result := <-Capture(ctx, q, resultsChan)
+	assert.ErrorIs(t, result.Err, errJob)
+	assert.ErrorIs(t, result.Err, errStore)
+}
+
+func TestCaptureCancelled(t *testing.T) {
+	errJob := errors.New("job failed")
+	tests := []struct {
+		name string
+		err  error
+		kept bool
+	}{
+		{name: "Cancelled by ID", err: workerpool.ErrJobCancelled},
+		{name: "Pool shut down", err: fmt.Errorf("job 1: %w", context.Canceled)},
+		{name: "Deadline exceeded", err: context.DeadlineExceeded, kept: true},
+		{name: "Failed", err: errJob, kept: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			q := New[int](NewMemoryStore[int]())
+			resultsChan := make(chan workerpool.Result[int, int], 1)
+			resultsChan <- workerpool.Result[int, int]{Job: workerpool.Job[int]{ID: 1}, Err: tt.err}
+			close(resultsChan)
+
+			result := <-Capture(ctx, q, resultsChan)
+			assert.Equal(t, tt.err, result.Err)
+
+			_, err := q.Inspect(1)
+			if tt.kept {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrNotFound)
+			}
+		})
+	}
+}
+
+// failingStore is a Store that fails to add entries.
+type failingStore struct {
+	Store[int]
+	err error
+}
+
+func (s failingStore) Put(Entry[int]) error {
+	return s.err
+}
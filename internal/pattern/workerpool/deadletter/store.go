@@ -0,0 +1,182 @@
+package deadletter
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Store persists dead-letter entries by job ID.
+// Implementations must be safe for concurrent use.
+type Store[T any] interface {
+	// Put adds an entry, replacing any entry with the same job ID.
+	Put(Entry[T]) error
+	// Get returns the entry with the given job ID, ErrNotFound if there is none.
+	Get(id int) (Entry[T], error)
+	// List returns all entries, ordered by job ID.
+	List() ([]Entry[T], error)
+	// Delete removes the entries with the given job IDs, IDs without an entry are ignored.
+	Delete(ids ...int) error
+}
+
+// MemoryStore is a Store that keeps entries in memory.
+type MemoryStore[T any] struct {
+	mu      sync.Mutex
+	entries map[int]Entry[T]
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore[T any]() *MemoryStore[T] {
+	return &MemoryStore[T]{entries: make(map[int]Entry[T])}
+}
+
+func (s *MemoryStore[T]) Put(entry Entry[T]) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[entry.Job.ID] = entry
+	return nil
+}
+
+func (s *MemoryStore[T]) Get(id int) (Entry[T], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return Entry[T]{}, fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	return entry, nil
+}
+
+func (s *MemoryStore[T]) List() ([]Entry[T], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]Entry[T], 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+	sortByID(entries)
+	return entries, nil
+}
+
+func (s *MemoryStore[T]) Delete(ids ...int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		delete(s.entries, id)
+	}
+	return nil
+}
+
+// FileStore is a Store that keeps every entry as a JSON file in a directory, so operators can also inspect them by hand.
+type FileStore[T any] struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore creates a FileStore in the given directory, creating the directory if needed.
+func NewFileStore[T any](dir string) (*FileStore[T], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("deadletter: create store: %w", err)
+	}
+	return &FileStore[T]{dir: dir}, nil
+}
+
+// Put writes the entry to a temporary file and renames it into place, so a crash never leaves a partial entry.
+func (s *FileStore[T]) Put(entry Entry[T]) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("deadletter: encode entry %d: %w", entry.Job.ID, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.path(entry.Job.ID)
+	if err := os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return fmt.Errorf("deadletter: write entry %d: %w", entry.Job.ID, err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("deadletter: write entry %d: %w", entry.Job.ID, err)
+	}
+	return nil
+}
+
+func (s *FileStore[T]) Get(id int) (Entry[T], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.read(s.path(id))
+}
+
+func (s *FileStore[T]) List() ([]Entry[T], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("deadletter: list entries: %w", err)
+	}
+
+	var entries []Entry[T]
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		entry, err := s.read(filepath.Join(s.dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	sortByID(entries)
+	return entries, nil
+}
+
+func (s *FileStore[T]) Delete(ids ...int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("deadletter: delete entry %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+func (s *FileStore[T]) path(id int) string {
+	return filepath.Join(s.dir, strconv.Itoa(id)+".json")
+}
+
+func (s *FileStore[T]) read(path string) (Entry[T], error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Entry[T]{}, fmt.Errorf("%w: %s", ErrNotFound, filepath.Base(path))
+	}
+	if err != nil {
+		return Entry[T]{}, fmt.Errorf("deadletter: read entry: %w", err)
+	}
+
+	var entry Entry[T]
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Entry[T]{}, fmt.Errorf("deadletter: decode %s: %w", filepath.Base(path), err)
+	}
+	return entry, nil
+}
+
+func sortByID[T any](entries []Entry[T]) {
+	slices.SortFunc(entries, func(a, b Entry[T]) int {
+		return cmp.Compare(a.Job.ID, b.Job.ID)
+	})
+}
@@ -0,0 +1,78 @@
+package deadletter
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/workerpool"
+)
+
+func TestStores(t *testing.T) {
+	tests := []struct {
+		name     string
+		newStore func(t *testing.T) Store[string]
+	}{
+		{
+			name: "Memory store",
+			newStore: func(t *testing.T) Store[string] {
+				return NewMemoryStore[string]()
+			},
+		},
+		{
+			name: "File store",
+			newStore: func(t *testing.T) Store[string] {
+				store, err := NewFileStore[string](t.TempDir())
+				require.NoError(t, err)
+				return store
+			},
+		},
+	}
+
+	failedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	entry := func(id int, value string) Entry[string] {
+		return Entry[string]{
+			Job:           workerpool.Job[string]{ID: id, Value: value},
+			Errors:        []string{"timeout", "connection refused"},
+			Attempts:      2,
+			FirstFailedAt: failedAt,
+			LastFailedAt:  failedAt,
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := tt.newStore(t)
+
+			require.NoError(t, store.Put(entry(2, "b")))
+			require.NoError(t, store.Put(entry(10, "c")))
+			require.NoError(t, store.Put(entry(1, "a")))
+
+			got, err := store.Get(2)
+			require.NoError(t, err)
+			assert.Equal(t, entry(2, "b"), got)
+
+			_, err = store.Get(3)
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			list, err := store.List()
+			require.NoError(t, err)
+			assert.Equal(t, []Entry[string]{entry(1, "a"), entry(2, "b"), entry(10, "c")}, list)
+
+			require.NoError(t, store.Delete(2, 3))
+			list, err = store.List()
+			require.NoError(t, err)
+			assert.Equal(t, []Entry[string]{entry(1, "a"), entry(10, "c")}, list)
+
+			// IDs far apart are still listed in order.
+			require.NoError(t, store.Put(entry(math.MaxInt, "z")))
+			require.NoError(t, store.Put(entry(math.MinInt, "y")))
+			list, err = store.List()
+			require.NoError(t, err)
+			assert.Equal(t, []Entry[string]{entry(math.MinInt, "y"), entry(1, "a"), entry(10, "c"), entry(math.MaxInt, "z")}, list)
+		})
+	}
+}
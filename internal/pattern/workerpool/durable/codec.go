@@ -0,0 +1,24 @@
+package durable
+
+import (
+	"encoding/json"
+)
+
+// Codec encodes job values to bytes stored in the log, and decodes them back.
+type Codec[T any] interface {
+	Encode(T) ([]byte, error)
+	Decode([]byte) (T, error)
+}
+
+// JSONCodec is a Codec that encodes values as JSON.
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(value T) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
@@ -0,0 +1,129 @@
+package durable
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// ErrCorrupt is returned when a record in the middle of the log is damaged, it fails its checksum or its length is invalid.
+var ErrCorrupt = errors.New("durable: corrupt log record")
+
+// op is the kind of event a record stores.
+type op byte
+
+const (
+	opEnqueue op = iota + 1 // a job was added to the queue, the record holds the encoded value
+	opAck                   // a job was acknowledged, the record holds no data
+	opNextID                // the ID of the next enqueued job, in place of the job ID, written by compaction
+)
+
+// record is a single event in the log.
+// On disk a record is a 4-byte payload length and a 4-byte CRC-32C checksum of the payload, followed by the payload:
+// a 1-byte op, an 8-byte job ID and the encoded value, if any.
+type record struct {
+	op   op
+	id   int
+	data []byte
+}
+
+const (
+	headerSize     = 8
+	payloadMinSize = 9
+	payloadMaxSize = 64 << 20 // larger payloads are never written, so a longer length can only be damage
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// appendRecord appends the on-disk form of a record to buf.
+func appendRecord(buf []byte, r record) []byte {
+	payloadSize := payloadMinSize + len(r.data)
+	start := len(buf)
+
+	buf = binary.BigEndian.AppendUint32(buf, uint32(payloadSize))
+	buf = binary.BigEndian.AppendUint32(buf, 0) // checksum, filled in below
+	buf = append(buf, byte(r.op))
+	buf = binary.BigEndian.AppendUint64(buf, uint64(r.id))
+	buf = append(buf, r.data...)
+
+	binary.BigEndian.PutUint32(buf[start+4:], crc32.Checksum(buf[start+headerSize:], crcTable))
+	return buf
+}
+
+// readRecords calls fn for every record in a log of the given size, in order.
+// It returns the size of the valid part of the log: a torn or corrupt last record, left behind by a crash
+// in the middle of a write, is not part of it. A damaged record followed by a valid record returns ErrCorrupt,
+// as does a record whose length is larger than any record that is ever written.
+func readRecords(r io.ReaderAt, size int64, fn func(record) error) (int64, error) {
+	br := bufio.NewReader(io.NewSectionReader(r, 0, size))
+	header := make([]byte, headerSize)
+
+	var offset int64
+	for offset < size {
+		if _, err := io.ReadFull(br, header); err != nil {
+			return offset, nil // torn header at the end of the log
+		}
+		payloadSize := int64(binary.BigEndian.Uint32(header))
+		end := offset + headerSize + payloadSize
+		if payloadSize > payloadMaxSize {
+			return offset, fmt.Errorf("%w at offset %d: length %d", ErrCorrupt, offset, payloadSize)
+		}
+		if payloadSize < payloadMinSize || end > size {
+			// Either a record torn at the end of the log, or a damaged length in the middle of it.
+			valid, err := validRecordAfter(r, offset, size)
+			if err != nil {
+				return offset, err
+			}
+			if valid {
+				return offset, fmt.Errorf("%w at offset %d: length %d", ErrCorrupt, offset, payloadSize)
+			}
+			return offset, nil
+		}
+
+		payload := make([]byte, payloadSize)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return offset, fmt.Errorf("durable: read log: %w", err)
+		}
+		if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(header[4:]) {
+			if end == size {
+				return offset, nil // partially written record at the end of the log
+			}
+			return offset, fmt.Errorf("%w at offset %d", ErrCorrupt, offset)
+		}
+
+		rec := record{
+			op:   op(payload[0]),
+			id:   int(binary.BigEndian.Uint64(payload[1:payloadMinSize])),
+			data: payload[payloadMinSize:],
+		}
+		if err := fn(rec); err != nil {
+			return offset, err
+		}
+		offset = end
+	}
+	return offset, nil
+}
+
+// validRecordAfter reports whether a valid record starts anywhere in the log after the damaged record at offset,
+// which tells a damaged length in the middle of the log from a record torn at its end.
+func validRecordAfter(r io.ReaderAt, offset int64, size int64) (bool, error) {
+	rest := make([]byte, size-offset-1)
+	if _, err := r.ReadAt(rest, offset+1); err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("durable: read log: %w", err)
+	}
+
+	for i := 0; i+headerSize+payloadMinSize <= len(rest); i++ {
+		payloadSize := int(binary.BigEndian.Uint32(rest[i:]))
+		end := i + headerSize + payloadSize
+		if payloadSize < payloadMinSize || payloadSize > payloadMaxSize || end > len(rest) {
+			continue
+		}
+		if crc32.Checksum(rest[i+headerSize:end], crcTable) == binary.BigEndian.Uint32(rest[i+4:]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
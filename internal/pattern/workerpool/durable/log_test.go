@@ -0,0 +1,96 @@
+package durable
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadRecords(t *testing.T) {
+	first := record{op: opEnqueue, id: 1, data: []byte(`"a"`)}
+	second := record{op: opAck, id: 1, data: []byte{}}
+	valid := appendRecord(appendRecord(nil, first), second)
+	firstSize := int64(len(appendRecord(nil, first)))
+
+	corrupt := func(log []byte, at int) []byte {
+		log = bytes.Clone(log)
+		log[at] ^= 0xff
+		return log
+	}
+
+	tests := []struct {
+		name      string
+		log       []byte
+		wantValid int64
+		want      []record
+		wantErr   error
+	}{
+		{
+			name:      "Empty log",
+			wantValid: 0,
+		},
+		{
+			name:      "Valid log",
+			log:       valid,
+			wantValid: int64(len(valid)),
+			want:      []record{first, second},
+		},
+		{
+			name:      "Torn header",
+			log:       valid[:firstSize+3],
+			wantValid: firstSize,
+			want:      []record{first},
+		},
+		{
+			name:      "Torn payload",
+			log:       valid[:len(valid)-1],
+			wantValid: firstSize,
+			want:      []record{first},
+		},
+		{
+			name:      "Corrupt last record",
+			log:       corrupt(valid, len(valid)-1),
+			wantValid: firstSize,
+			want:      []record{first},
+		},
+		{
+			name:      "Zeroed tail",
+			log:       append(bytes.Clone(valid), make([]byte, 20)...),
+			wantValid: int64(len(valid)),
+			want:      []record{first, second},
+		},
+		{
+			name:      "Length too large",
+			log:       corrupt(valid, 0),
+			wantValid: 0,
+			wantErr:   ErrCorrupt,
+		},
+		{
+			name:      "Length beyond the end followed by more records",
+			log:       corrupt(valid, 3),
+			wantValid: 0,
+			wantErr:   ErrCorrupt,
+		},
+		{
+			name:      "Corrupt record followed by more records",
+			log:       corrupt(valid, headerSize),
+			wantValid: 0,
+			wantErr:   ErrCorrupt,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []record
+			gotValid, err := readRecords(bytes.NewReader(tt.log), int64(len(tt.log)), func(rec record) error {
+				got = append(got, rec)
+				return nil
+			})
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantValid, gotValid)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package durable
+
+import (
+	"time"
+)
+
+// Option configures optional behaviour of a Queue.
+type Option func(*config)
+
+type config struct {
+	compactInterval time.Duration
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{compactInterval: time.Minute}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithCompactInterval sets how often the log is checked for compaction in the background, one minute by default.
+// A zero or negative interval disables background compaction, Compact can still be called directly.
+func WithCompactInterval(d time.Duration) Option {
+	return func(c *config) {
+		c.compactInterval = d
+	}
+}
@@ -0,0 +1,385 @@
+// Package durable provides a file-backed job queue that survives restarts, to feed the worker pools.
+package durable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/workerpool"
+)
+
+var (
+	// ErrClosed is returned by operations on a closed queue.
+	ErrClosed = errors.New("durable: queue closed")
+	// ErrUnknownJob is returned when acknowledging a job that is not pending.
+	ErrUnknownJob = errors.New("durable: unknown job")
+	// ErrTooLarge is returned when enqueuing a job whose encoded value does not fit in a log record.
+	ErrTooLarge = errors.New("durable: job too large")
+)
+
+// Queue is a job queue backed by an append-only log file.
+// Every enqueued job is written to the log before Enqueue returns, and stays pending until it is acknowledged.
+// Jobs that were not acknowledged are replayed when the queue is opened again,
+// so a job is delivered at least once, and again after a restart if it was not acknowledged in time.
+type Queue[T any] struct {
+	path  string
+	codec Codec[T]
+	cfg   config
+
+	mu      sync.Mutex
+	file    *os.File
+	size    int64            // size of the log file
+	records int              // number of records in the log file
+	buf     []byte           // reused to encode records
+	nextID  int              // ID of the next enqueued job
+	pending map[int]entry[T] // jobs not acknowledged yet
+	ready   []int            // IDs of pending jobs not delivered yet, in enqueue order
+	signal  chan struct{}    // closed and replaced when a job becomes ready
+	closed  chan struct{}
+
+	wg sync.WaitGroup
+}
+
+// entry is a pending job, with its encoded value kept for compaction.
+type entry[T any] struct {
+	value T
+	data  []byte
+}
+
+// Open opens the queue stored in the log file at path, creating it if needed, and replays its pending jobs.
+// A record torn by a crash in the middle of a write is discarded. A record damaged in the middle of the log
+// returns ErrCorrupt, leaving the log file untouched.
+func Open[T any](path string, codec Codec[T], opts ...Option) (*Queue[T], error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("durable: open log: %w", err)
+	}
+
+	q := &Queue[T]{
+		path:    path,
+		codec:   codec,
+		cfg:     newConfig(opts),
+		file:    file,
+		pending: make(map[int]entry[T]),
+		signal:  make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	if err := q.replay(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	if q.cfg.compactInterval > 0 {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			q.compactPeriodically()
+		}()
+	}
+	return q, nil
+}
+
+// replay rebuilds the pending jobs from the log, and truncates a torn last record.
+func (q *Queue[T]) replay() error {
+	info, err := q.file.Stat()
+	if err != nil {
+		return fmt.Errorf("durable: stat log: %w", err)
+	}
+
+	q.size, err = readRecords(q.file, info.Size(), func(rec record) error {
+		switch rec.op {
+		case opEnqueue:
+			value, err := q.codec.Decode(rec.data)
+			if err != nil {
+				return fmt.Errorf("durable: decode job %d: %w", rec.id, err)
+			}
+			q.pending[rec.id] = entry[T]{value: value, data: rec.data}
+			q.nextID = max(q.nextID, rec.id+1)
+		case opAck:
+			delete(q.pending, rec.id)
+		case opNextID:
+			q.nextID = max(q.nextID, rec.id)
+			return nil // not a job record, it is never stale
+		default:
+			return fmt.Errorf("%w: unknown op %d", ErrCorrupt, rec.op)
+		}
+		q.records++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if q.size < info.Size() {
+		if err := q.file.Truncate(q.size); err != nil {
+			return fmt.Errorf("durable: truncate torn record: %w", err)
+		}
+	}
+
+	for id := range q.pending {
+		q.ready = append(q.ready, id)
+	}
+	slices.Sort(q.ready)
+	return nil
+}
+
+// Enqueue adds a job with the given value to the queue, and returns it once it is written to the log.
+func (q *Queue[T]) Enqueue(value T) (workerpool.Job[T], error) {
+	data, err := q.codec.Encode(value)
+	if err != nil {
+		return workerpool.Job[T]{}, fmt.Errorf("durable: encode job: %w", err)
+	}
+	if len(data) > payloadMaxSize-payloadMinSize {
+		return workerpool.Job[T]{}, fmt.Errorf("%w: %d bytes", ErrTooLarge, len(data))
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.isClosed() {
+		return workerpool.Job[T]{}, ErrClosed
+	}
+	job := workerpool.Job[T]{ID: q.nextID, Value: value}
+	if err := q.write(record{op: opEnqueue, id: job.ID, data: data}); err != nil {
+		return workerpool.Job[T]{}, err
+	}
+
+	q.nextID++
+	q.pending[job.ID] = entry[T]{value: value, data: data}
+	q.ready = append(q.ready, job.ID)
+	close(q.signal)
+	q.signal = make(chan struct{})
+	return job, nil
+}
+
+// Ack acknowledges a job, so it is not replayed after a restart.
+func (q *Queue[T]) Ack(id int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.isClosed() {
+		return ErrClosed
+	}
+	if _, ok := q.pending[id]; !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownJob, id)
+	}
+	if err := q.write(record{op: opAck, id: id}); err != nil {
+		return err
+	}
+
+	delete(q.pending, id)
+	return nil
+}
+
+// Pending returns the number of jobs not acknowledged yet.
+func (q *Queue[T]) Pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.pending)
+}
+
+// Jobs returns a channel that delivers the pending jobs in enqueue order, followed by new jobs as they are enqueued.
+// Every job is delivered once per opened queue, even across channels returned by multiple calls.
+// The channel is closed when the context is cancelled or the queue is closed.
+func (q *Queue[T]) Jobs(ctx context.Context) <-chan workerpool.Job[T] {
+	jobs := make(chan workerpool.Job[T])
+
+	go func() {
+		defer close(jobs)
+
+		for {
+			job, ok := q.next(ctx)
+			if !ok {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				q.putBack(job.ID)
+				return
+			case <-q.closed:
+				return
+			case jobs <- job:
+			}
+		}
+	}()
+
+	return jobs
+}
+
+// next takes the next ready job, waiting for one to be enqueued if needed.
+// It returns false if the context is cancelled or the queue is closed first.
+func (q *Queue[T]) next(ctx context.Context) (workerpool.Job[T], bool) {
+	for {
+		q.mu.Lock()
+		for len(q.ready) > 0 {
+			id := q.ready[0]
+			q.ready = q.ready[1:]
+			if e, ok := q.pending[id]; ok { // skip jobs acknowledged before they were delivered
+				q.mu.Unlock()
+				return workerpool.Job[T]{ID: id, Value: e.value}, true
+			}
+		}
+		signal := q.signal
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return workerpool.Job[T]{}, false
+		case <-q.closed:
+			return workerpool.Job[T]{}, false
+		case <-signal:
+		}
+	}
+}
+
+// putBack returns a job that was taken but not delivered to the front of the ready jobs.
+func (q *Queue[T]) putBack(id int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.ready = slices.Insert(q.ready, 0, id)
+}
+
+// Compact rewrites the log with only the pending jobs and the ID of the next job, dropping the records of acknowledged jobs.
+// The new log replaces the old one atomically, so a crash during compaction leaves either of them intact.
+func (q *Queue[T]) Compact() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.isClosed() {
+		return ErrClosed
+	}
+
+	ids := make([]int, 0, len(q.pending))
+	for id := range q.pending {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	// Keep the ID of the next job, so IDs are not reused after a restart once all jobs were acknowledged.
+	buf := appendRecord(nil, record{op: opNextID, id: q.nextID})
+	for _, id := range ids {
+		buf = appendRecord(buf, record{op: opEnqueue, id: id, data: q.pending[id].data})
+	}
+
+	tmp := q.path + ".compact"
+	if err := writeFileSync(tmp, buf); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("durable: compact: %w", err)
+	}
+	if err := os.Rename(tmp, q.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("durable: compact: %w", err)
+	}
+	syncDir(filepath.Dir(q.path))
+
+	file, err := os.OpenFile(q.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("durable: reopen log: %w", err)
+	}
+	_ = q.file.Close()
+	q.file = file
+	q.size = int64(len(buf))
+	q.records = len(ids)
+	return nil
+}
+
+// Close stops background compaction and closes the log file.
+// Channels returned by Jobs are closed, jobs that were not acknowledged are replayed when the queue is opened again.
+func (q *Queue[T]) Close() error {
+	q.mu.Lock()
+	if q.isClosed() {
+		q.mu.Unlock()
+		return nil
+	}
+	close(q.closed)
+	err := q.file.Close()
+	q.mu.Unlock()
+
+	q.wg.Wait()
+	return err
+}
+
+// write appends a record to the log and syncs it to disk.
+// A failed write is rolled back, so no torn record is left in the middle of the log.
+func (q *Queue[T]) write(rec record) error {
+	q.buf = appendRecord(q.buf[:0], rec)
+
+	_, err := q.file.Write(q.buf)
+	if err == nil {
+		err = q.file.Sync()
+	}
+	if err != nil {
+		_ = q.file.Truncate(q.size)
+		return fmt.Errorf("durable: write log: %w", err)
+	}
+
+	q.size += int64(len(q.buf))
+	q.records++
+	return nil
+}
+
+func (q *Queue[T]) isClosed() bool {
+	select {
+	case <-q.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// compactPeriodically compacts the log once the records of acknowledged jobs outnumber the pending jobs.
+// A failed compaction leaves the log as it was, and is tried again at the next interval.
+func (q *Queue[T]) compactPeriodically() {
+	ticker := time.NewTicker(q.cfg.compactInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-q.closed:
+			return
+		case <-ticker.C:
+			q.mu.Lock()
+			stale := q.records - len(q.pending)
+			compact := stale > 0 && stale >= len(q.pending)
+			q.mu.Unlock()
+
+			if compact {
+				_ = q.Compact()
+			}
+		}
+	}
+}
+
+// writeFileSync writes data to a new file at path and syncs it to disk.
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// syncDir syncs a directory, so a rename in it survives a crash. Errors are ignored, as not every platform supports it.
+func syncDir(path string) {
+	dir, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	_ = dir.Sync()
+	_ = dir.Close()
+}
@@ -0,0 +1,218 @@
+package durable
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/workerpool"
+)
+
+func TestQueueReplay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	path := filepath.Join(t.TempDir(), "jobs.wal")
+
+	q, err := Open[string](path, JSONCodec[string]{})
+	require.NoError(t, err)
+	for _, value := range []string{"a", "b", "c"} {
+		_, err := q.Enqueue(value)
+		require.NoError(t, err)
+	}
+	require.NoError(t, q.Ack(1))
+	assert.ErrorIs(t, q.Ack(1), ErrUnknownJob)
+	require.NoError(t, q.Close())
+
+	// Reopen the queue as after a restart, only the jobs that were not acknowledged are replayed.
+	q, err = Open[string](path, JSONCodec[string]{})
+	require.NoError(t, err)
+	defer q.Close()
+	assert.Equal(t, 2, q.Pending())
+
+	job, err := q.Enqueue("d")
+	require.NoError(t, err)
+	assert.Equal(t, 3, job.ID)
+
+	jobs := q.Jobs(ctx)
+	for _, want := range []workerpool.Job[string]{{ID: 0, Value: "a"}, {ID: 2, Value: "c"}, {ID: 3, Value: "d"}} {
+		assert.Equal(t, want, <-jobs)
+	}
+}
+
+func TestQueueTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.wal")
+
+	q, err := Open[string](path, JSONCodec[string]{})
+	require.NoError(t, err)
+	_, err = q.Enqueue("a")
+	require.NoError(t, err)
+	require.NoError(t, q.Close())
+
+	// Simulate a crash in the middle of writing a record.
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	torn := appendRecord(nil, record{op: opEnqueue, id: 1, data: []byte(`"b"`)})
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	require.NoError(t, err)
+	_, err = file.Write(torn[:len(torn)-2])
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	q, err = Open[string](path, JSONCodec[string]{})
+	require.NoError(t, err)
+	defer q.Close()
+	assert.Equal(t, 1, q.Pending())
+
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, info.Size(), after.Size()) // torn record truncated
+}
+
+func TestQueueCorruptLength(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.wal")
+
+	q, err := Open[string](path, JSONCodec[string]{})
+	require.NoError(t, err)
+	var second int64
+	for i, value := range []string{"a", "b", "c", "d"} {
+		_, err := q.Enqueue(value)
+		require.NoError(t, err)
+		if i == 0 {
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			second = info.Size()
+		}
+	}
+	require.NoError(t, q.Close())
+
+	// Damage the length of the second record, in the middle of the log.
+	log, err := os.ReadFile(path)
+	require.NoError(t, err)
+	log[second+3] ^= 0x40
+	require.NoError(t, os.WriteFile(path, log, 0o644))
+
+	_, err = Open[string](path, JSONCodec[string]{})
+	assert.ErrorIs(t, err, ErrCorrupt)
+
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, log, after, "the log is left untouched")
+}
+
+func TestQueueCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.wal")
+
+	q, err := Open[int](path, JSONCodec[int]{})
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		job, err := q.Enqueue(i)
+		require.NoError(t, err)
+		if i < 8 {
+			require.NoError(t, q.Ack(job.ID))
+		}
+	}
+	before, err := os.Stat(path)
+	require.NoError(t, err)
+
+	require.NoError(t, q.Compact())
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Less(t, after.Size(), before.Size())
+
+	// The queue keeps working on the compacted log.
+	_, err = q.Enqueue(10)
+	require.NoError(t, err)
+	require.NoError(t, q.Close())
+
+	q, err = Open[int](path, JSONCodec[int]{})
+	require.NoError(t, err)
+	defer q.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	jobs := q.Jobs(ctx)
+	for _, want := range []workerpool.Job[int]{{ID: 8, Value: 8}, {ID: 9, Value: 9}, {ID: 10, Value: 10}} {
+		assert.Equal(t, want, <-jobs)
+	}
+}
+
+func TestQueueBackgroundCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.wal")
+
+	q, err := Open[int](path, JSONCodec[int]{}, WithCompactInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	defer q.Close()
+
+	for i := 0; i < 10; i++ {
+		job, err := q.Enqueue(i)
+		require.NoError(t, err)
+		require.NoError(t, q.Ack(job.ID))
+	}
+
+	// Only the ID of the next job is left.
+	compacted := int64(len(appendRecord(nil, record{op: opNextID, id: 10})))
+	assert.Eventually(t, func() bool {
+		info, err := os.Stat(path)
+		return err == nil && info.Size() == compacted
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestQueueCompactKeepsNextID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.wal")
+
+	q, err := Open[int](path, JSONCodec[int]{})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		job, err := q.Enqueue(i)
+		require.NoError(t, err)
+		require.NoError(t, q.Ack(job.ID))
+	}
+	require.NoError(t, q.Compact())
+	require.NoError(t, q.Close())
+
+	// Every job was acknowledged and compacted away, the IDs of the acknowledged jobs are still not reused.
+	q, err = Open[int](path, JSONCodec[int]{})
+	require.NoError(t, err)
+	defer q.Close()
+	assert.Equal(t, 0, q.Pending())
+
+	job, err := q.Enqueue(3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, job.ID)
+}
+
+func TestQueueFeedsWorkerPool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	q, err := Open[int](filepath.Join(t.TempDir(), "jobs.wal"), JSONCodec[int]{})
+	require.NoError(t, err)
+	defer q.Close()
+
+	const numJobs = 20
+	for i := 0; i < numJobs; i++ {
+		_, err := q.Enqueue(i)
+		require.NoError(t, err)
+	}
+
+	resultsChan := make(chan workerpool.Result[int, int])
+	process := func(_ context.Context, value int) (int, error) {
+		return value * 2, nil
+	}
+	workerpool.CreateWorkerPool(ctx, 4, q.Jobs(ctx), resultsChan, process)
+
+	for i := 0; i < numJobs; i++ {
+		result := <-resultsChan
+		assert.NoError(t, result.Err)
+		assert.Equal(t, result.Job.Value*2, result.Value)
+		require.NoError(t, q.Ack(result.Job.ID))
+	}
+	assert.Equal(t, 0, q.Pending())
+}
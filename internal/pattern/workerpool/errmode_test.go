@@ -0,0 +1,107 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerPoolErrorMode(t *testing.T) {
+	errOdd := errors.New("odd value")
+	process := func(_ context.Context, value int) (int, error) {
+		if value%2 == 1 {
+			return 0, errOdd
+		}
+		return value, nil
+	}
+
+	tests := []struct {
+		name    string
+		mode    ErrorMode
+		wantErr string
+	}{
+		{
+			name: "Continue on error",
+			mode: ContinueOnError,
+		},
+		{
+			name:    "Collect all",
+			mode:    CollectAll,
+			wantErr: "job 1: odd value\njob 3: odd value\njob 5: odd value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			jobsChan := make(chan Job[int], 6)
+			resultsChan := make(chan Result[int, int], 6)
+			var report ErrorReport
+			CreateWorkerPool(ctx, 3, jobsChan, resultsChan, process, WithErrorMode(tt.mode, &report))
+
+			for i := 0; i < 6; i++ {
+				jobsChan <- Job[int]{ID: i, Value: i}
+			}
+			close(jobsChan)
+
+			count := 0
+			for range resultsChan {
+				count++
+			}
+			assert.Equal(t, 6, count) // all jobs finish in either mode
+
+			if tt.wantErr == "" {
+				assert.NoError(t, report.Err())
+				return
+			}
+			assert.EqualError(t, report.Err(), tt.wantErr)
+			assert.ErrorIs(t, report.Err(), errOdd)
+		})
+	}
+}
+
+func TestWorkerPoolFailFast(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	errBoom := errors.New("boom")
+	started := make(chan struct{})
+	process := func(ctx context.Context, value int) (int, error) {
+		if value == 1 {
+			<-started
+			return 0, errBoom
+		}
+		close(started)
+		<-ctx.Done() // runs until the first error cancels it
+		return 0, context.Cause(ctx)
+	}
+
+	jobsChan := make(chan Job[int], 3)
+	resultsChan := make(chan Result[int, int], 3)
+	var report ErrorReport
+	pool := CreateWorkerPool(ctx, 2, jobsChan, resultsChan, process, WithErrorMode(FailFast, &report))
+
+	jobsChan <- Job[int]{ID: 1, Value: 1}
+	jobsChan <- Job[int]{ID: 2, Value: 2}
+
+	got := map[int]Result[int, int]{}
+	for result := range resultsChan {
+		got[result.Job.ID] = result
+	}
+	assert.Empty(t, pool.Stop())
+
+	var jobErr *JobError
+	require.ErrorAs(t, report.Err(), &jobErr)
+	assert.Equal(t, 1, jobErr.JobID)
+	assert.ErrorIs(t, report.Err(), errBoom)
+
+	// The outstanding job was cancelled with the first error as the cause.
+	require.ErrorAs(t, got[2].Err, &jobErr)
+	assert.Equal(t, 1, jobErr.JobID)
+	assert.Nil(t, ctx.Err()) // the caller's context is left alone
+}
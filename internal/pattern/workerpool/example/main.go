@@ -27,8 +27,9 @@ func main() {
 	jobs := make(chan workerpool.Job[int])
 	results := make(chan workerpool.Result[int, string])
 
-	// Create a worker pool with 3 workers.
-	workerpool.CreateWorkerPool(ctx, 3, jobs, results, FetchPokemonName)
+	// Create a worker pool with 3 workers, that stops on the first error.
+	var report workerpool.ErrorReport
+	workerpool.CreateWorkerPool(ctx, 3, jobs, results, FetchPokemonName, workerpool.WithErrorMode(workerpool.FailFast, &report))
 
 	// This goroutine sends a new job every second.
 	go func() {
@@ -47,9 +48,11 @@ func main() {
 	for result := range results {
 		if result.Err != nil {
 			slog.Error("Error processing job", "jobID", result.Job.ID, "error", result.Err)
-			cancel()
 			continue
 		}
 		slog.Info("Result for job", "jobID", result.Job.ID, "result", result.Value)
 	}
+	if err := report.Err(); err != nil {
+		slog.Error("Stopped after the first error", "error", err)
+	}
 }
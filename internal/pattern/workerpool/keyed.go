@@ -0,0 +1,127 @@
+package workerpool
+
+import (
+	"context"
+	"time"
+)
+
+// KeyFunc returns the partition key of a job, jobs with the same key are processed one at a time in arrival order.
+type KeyFunc[T any, K comparable] func(Job[T]) K
+
+// CreateKeyedWorkerPool creates a pool of workers that partitions jobs by key.
+// Jobs with the same key are processed strictly one after another in arrival order,
+// while jobs with different keys are processed in parallel.
+// Every key has its own queue, so a key with a long backlog or a slow job never holds up jobs of other keys.
+func CreateKeyedWorkerPool[T any, U any, K comparable](ctx context.Context, numWorkers int, jobs <-chan Job[T], results chan<- Result[T, U], key KeyFunc[T, K], process ProcessFunc[T, U], opts ...Option) *WorkerPool[T, U] {
+	p := newWorkerPool(ctx, results, process, opts)
+
+	finished := make(chan uint64)
+	p.afterRun = func(t task[T]) {
+		select {
+		case finished <- t.ticket:
+		case <-p.dispatched: // the dispatcher is gone, nothing to release
+		}
+	}
+	go dispatchKeyed(p, jobs, key, finished)
+	p.Resize(numWorkers)
+
+	return p
+}
+
+// lane holds the pending jobs of a single key.
+type lane[T any] struct {
+	pending []task[T]
+	running bool // a job of this key is being processed
+}
+
+// dispatchKeyed queues the received jobs per key and hands the oldest job of every idle key to the next free worker.
+// A key is idle once the result of its previous job was delivered.
+func dispatchKeyed[T any, U any, K comparable](p *WorkerPool[T, U], jobs <-chan Job[T], key KeyFunc[T, K], finished <-chan uint64) {
+	defer p.closeTasks()
+
+	var (
+		lanes   = map[K]*lane[T]{}
+		ready   []K              // keys with pending jobs and no running job, in the order they became ready
+		running = map[uint64]K{} // keys of the running jobs by ticket
+		ticket  uint64
+		seq     reservation
+	)
+	defer p.unreserve(&seq)
+	defer func() {
+		// Keep the jobs that were never handed to a worker.
+		for _, l := range lanes {
+			for _, t := range l.pending {
+				p.addLeftover(t.job)
+			}
+		}
+	}()
+
+	// enqueue adds a received job to the queue of its key.
+	enqueue := func(job Job[T]) {
+		p.accept(job)
+
+		k := key(job)
+		l, exists := lanes[k]
+		if !exists {
+			l = &lane[T]{}
+			lanes[k] = l
+		}
+		l.pending = append(l.pending, task[T]{job: job, queued: time.Now()})
+		if !l.running && len(l.pending) == 1 {
+			ready = append(ready, k)
+		}
+	}
+
+	draining := p.draining
+	for jobs != nil || len(lanes) > 0 {
+		// Only offer a task when there is one, a nil channel blocks forever.
+		var (
+			tasks chan<- task[T]
+			next  task[T]
+		)
+		if len(ready) > 0 {
+			if !p.reserve(&seq) {
+				return
+			}
+			tasks = p.tasks
+			next = lanes[ready[0]].pending[0]
+			next.seq = seq.seq
+			next.ticket = ticket
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-p.done:
+			return
+		case <-draining:
+			takeBuffered(jobs, enqueue)
+			jobs, draining = nil, nil // no more jobs are accepted, drain the queues
+		case job, ok := <-jobs:
+			if !ok {
+				jobs = nil // jobs channel closed, drain the queues
+				continue
+			}
+			enqueue(job)
+		case tasks <- next:
+			k := ready[0]
+			ready = ready[1:]
+			l := lanes[k]
+			l.pending = l.pending[1:]
+			l.running = true
+			running[ticket] = k
+			ticket++
+			seq.held = false
+		case t := <-finished:
+			k := running[t]
+			delete(running, t)
+			l := lanes[k]
+			l.running = false
+			if len(l.pending) > 0 {
+				ready = append(ready, k)
+			} else {
+				delete(lanes, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedWorkerPool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	const numKeys, jobsPerKey = 4, 25
+	var (
+		mu        sync.Mutex
+		running   = map[int]bool{}
+		processed = map[int][]int{}
+	)
+	process := func(_ context.Context, value int) (int, error) {
+		key := value % numKeys
+
+		mu.Lock()
+		assert.False(t, running[key], "jobs of key %d overlap", key)
+		running[key] = true
+		processed[key] = append(processed[key], value)
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		running[key] = false
+		mu.Unlock()
+		return value, nil
+	}
+
+	jobsChan := make(chan Job[int], numKeys*jobsPerKey)
+	resultsChan := make(chan Result[int, int], numKeys*jobsPerKey)
+	keyOf := func(job Job[int]) int { return job.Value % numKeys }
+	CreateKeyedWorkerPool(ctx, numKeys, jobsChan, resultsChan, keyOf, process)
+
+	for i := 0; i < numKeys*jobsPerKey; i++ {
+		jobsChan <- Job[int]{ID: i, Value: i}
+	}
+	close(jobsChan)
+
+	gotResults := map[int][]int{}
+	for result := range resultsChan {
+		gotResults[keyOf(result.Job)] = append(gotResults[keyOf(result.Job)], result.Value)
+	}
+
+	for key := 0; key < numKeys; key++ {
+		var want []int
+		for i := key; i < numKeys*jobsPerKey; i += numKeys {
+			want = append(want, i)
+		}
+		assert.Equal(t, want, processed[key])  // processed in arrival order
+		assert.Equal(t, want, gotResults[key]) // delivered in arrival order
+	}
+}
+
+func TestKeyedWorkerPoolNoHeadOfLineBlocking(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	release := make(chan struct{})
+	process := func(_ context.Context, key string) (string, error) {
+		if key == "slow" {
+			<-release
+		}
+		return key, nil
+	}
+
+	jobsChan := make(chan Job[string], 4)
+	resultsChan := make(chan Result[string, string], 4)
+	keyOf := func(job Job[string]) string { return job.Value }
+	CreateKeyedWorkerPool(ctx, 2, jobsChan, resultsChan, keyOf, process)
+
+	jobsChan <- Job[string]{ID: 1, Value: "slow"}
+	jobsChan <- Job[string]{ID: 2, Value: "slow"}
+	jobsChan <- Job[string]{ID: 3, Value: "fast"}
+	jobsChan <- Job[string]{ID: 4, Value: "fast"}
+	close(jobsChan)
+
+	// The fast key finishes while the slow key is blocked.
+	assert.Equal(t, 3, (<-resultsChan).Job.ID)
+	assert.Equal(t, 4, (<-resultsChan).Job.ID)
+
+	close(release)
+	assert.Equal(t, 1, (<-resultsChan).Job.ID)
+	assert.Equal(t, 2, (<-resultsChan).Job.ID)
+}
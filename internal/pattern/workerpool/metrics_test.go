@@ -0,0 +1,89 @@
+package workerpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/metrics"
+)
+
+func TestWorkerPoolMetrics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	jobs := []Job[int]{{ID: 1, Value: 2}, {ID: 2, Value: -1}, {ID: 3, Value: 3}}
+	jobsChan := make(chan Job[int], len(jobs))
+	resultsChan := make(chan Result[int, int], len(jobs))
+
+	m := metrics.NewInMemory("workerpool")
+	CreateWorkerPool(ctx, 2, jobsChan, resultsChan, squareNonNegative, WithMetrics(m))
+
+	for _, job := range jobs {
+		jobsChan <- job
+	}
+	close(jobsChan)
+	for range resultsChan {
+	}
+
+	assert.Equal(t, metrics.Snapshot{Completed: 2, Failed: 1}, m.Snapshot())
+}
+
+func TestWorkerPoolMetricsDropped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	release := make(chan struct{})
+	process := func(_ context.Context, value int) (int, error) {
+		<-release
+		return value, nil
+	}
+
+	jobsChan := make(chan Job[int], 2)
+	resultsChan := make(chan Result[int, int], 2)
+	m := metrics.NewInMemory("workerpool")
+	pool := CreateWorkerPool(ctx, 1, jobsChan, resultsChan, process, WithMetrics(m))
+
+	jobsChan <- Job[int]{ID: 1, Value: 1}
+	jobsChan <- Job[int]{ID: 2, Value: 2}
+	assert.Eventually(t, func() bool {
+		return m.Snapshot() == metrics.Snapshot{Queued: 1, InFlight: 1}
+	}, time.Second, time.Millisecond)
+
+	// The job waiting for a worker is left over, it is no longer counted as queued.
+	assert.Equal(t, []Job[int]{{ID: 2, Value: 2}}, pool.Stop())
+	close(release)
+	pool.Wait()
+
+	assert.Equal(t, metrics.Snapshot{Completed: 1, Dropped: 1}, m.Snapshot())
+}
+
+func TestWorkStealingPoolMetricsDropped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	process := func(ctx context.Context, _ int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+
+	jobsChan := make(chan Job[int], 3)
+	resultsChan := make(chan Result[int, int], 3)
+	m := metrics.NewInMemory("workerpool")
+	pool := CreateWorkStealingPool(ctx, 1, jobsChan, resultsChan, process, WithMetrics(m))
+
+	for i := 1; i <= 3; i++ {
+		jobsChan <- Job[int]{ID: i, Value: i}
+	}
+	assert.Eventually(t, func() bool {
+		return m.Snapshot() == metrics.Snapshot{Queued: 2, InFlight: 1}
+	}, time.Second, time.Millisecond)
+
+	// The jobs left in the deques once the context is cancelled are dropped.
+	cancel()
+	pool.Wait()
+
+	assert.Equal(t, metrics.Snapshot{Failed: 1, Dropped: 2}, m.Snapshot())
+}
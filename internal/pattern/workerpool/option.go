@@ -0,0 +1,94 @@
+package workerpool
+
+import (
+	"time"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/metrics"
+)
+
+// config holds the optional settings of a worker pool.
+type config struct {
+	retry      RetryPolicy
+	jobTimeout time.Duration
+
+	restartOnPanic bool
+
+	aging time.Duration
+
+	orderWindow int
+
+	metrics metrics.Metrics
+
+	errorMode   ErrorMode
+	errorReport *ErrorReport
+}
+
+// Option configures optional behaviour of a worker pool.
+type Option func(*config)
+
+// WithRetryPolicy sets the policy used to retry failed jobs.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *config) {
+		c.retry = policy
+	}
+}
+
+// WithJobTimeout bounds every attempt to process a job by the given timeout.
+// The timeout is applied to the context passed to the ProcessFunc.
+func WithJobTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.jobTimeout = timeout
+	}
+}
+
+// WithRestartOnPanic replaces a worker goroutine with a fresh one after its ProcessFunc panicked.
+// Panics are always recovered and reported as a PanicError, with or without this option.
+func WithRestartOnPanic() Option {
+	return func(c *config) {
+		c.restartOnPanic = true
+	}
+}
+
+// WithAging raises the priority of a pending job by one level for every interval it waits.
+// It only applies to pools created with CreatePriorityWorkerPool.
+func WithAging(interval time.Duration) Option {
+	return func(c *config) {
+		c.aging = interval
+	}
+}
+
+// WithOrderedResults releases results in the order jobs were submitted to the workers.
+// At most window jobs can be in flight or waiting for an earlier job to finish,
+// once the window is full no more jobs are taken until the oldest result is released.
+func WithOrderedResults(window int) Option {
+	return func(c *config) {
+		c.orderWindow = max(window, 1)
+	}
+}
+
+// WithMetrics reports the lifecycle of every job to the given metrics.
+func WithMetrics(m metrics.Metrics) Option {
+	return func(c *config) {
+		c.metrics = m
+	}
+}
+
+// WithErrorMode sets how the errors of jobs are handled, and the report that receives the error summary.
+// FailFast cancels the context of the outstanding jobs on the first error, with its JobError as the cause,
+// CollectAll lets all jobs finish and reports the JobError of every failed job, joined.
+// Jobs cancelled by ID with Cancel don't count as failed.
+// The report is complete once the results channel is closed, it may be nil if only the behaviour is needed.
+func WithErrorMode(mode ErrorMode, report *ErrorReport) Option {
+	return func(c *config) {
+		c.errorMode = mode
+		c.errorReport = report
+	}
+}
+
+func newConfig(opts []Option) config {
+	c := config{metrics: metrics.Nop{}}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
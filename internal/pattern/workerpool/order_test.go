@@ -0,0 +1,40 @@
+package workerpool
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// slowFirst sleeps longer for lower values, so results complete in reverse order.
+func slowFirst(_ context.Context, value int) (int, error) {
+	time.Sleep(time.Duration(10-value) * time.Millisecond)
+	return value, nil
+}
+
+func TestWorkerPoolOrderedResults(t *testing.T) {
+	for _, window := range []int{1, 3, 10} {
+		t.Run("Window "+strconv.Itoa(window), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			jobsChan := make(chan Job[int], 10)
+			resultsChan := make(chan Result[int, int])
+			CreateWorkerPool(ctx, 4, jobsChan, resultsChan, slowFirst, WithOrderedResults(window))
+
+			for i := 0; i < 10; i++ {
+				jobsChan <- Job[int]{ID: i, Value: i}
+			}
+			close(jobsChan)
+
+			var gotIDs []int
+			for result := range resultsChan {
+				gotIDs = append(gotIDs, result.Job.ID)
+			}
+			assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, gotIDs)
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package workerpool
+
+import (
+	"errors"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/panics"
+)
+
+// PanicError is reported in Result.Err when a ProcessFunc panics.
+// It is the same type in every executor package, so errors.As matches it whichever executor ran the job.
+type PanicError = panics.Error
+
+// panicked reports whether any of the errors is a PanicError.
+func panicked(errs []error) bool {
+	for _, err := range errs {
+		var panicErr *PanicError
+		if errors.As(err, &panicErr) {
+			return true
+		}
+	}
+	return false
+}
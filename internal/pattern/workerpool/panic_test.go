@@ -0,0 +1,61 @@
+package workerpool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// panicOnNegative squares a value and panics on negative values.
+func panicOnNegative(_ context.Context, value int) (int, error) {
+	if value < 0 {
+		panic("negative value")
+	}
+	return value * value, nil
+}
+
+func TestWorkerPoolPanic(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{name: "Keep Worker"},
+		{name: "Restart Worker", opts: []Option{WithRestartOnPanic()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			jobs := []Job[int]{{ID: 1, Value: -1}, {ID: 2, Value: 2}, {ID: 3, Value: -3}, {ID: 4, Value: 4}}
+			jobsChan := make(chan Job[int], len(jobs))
+			resultsChan := make(chan Result[int, int], len(jobs))
+			pool := CreateWorkerPool(ctx, 1, jobsChan, resultsChan, panicOnNegative, tt.opts...)
+
+			for _, job := range jobs {
+				jobsChan <- job
+			}
+
+			for range jobs {
+				result := <-resultsChan
+				if result.Job.Value >= 0 {
+					assert.NoError(t, result.Err)
+					assert.Equal(t, result.Job.Value*result.Job.Value, result.Value)
+					continue
+				}
+
+				var panicErr *PanicError
+				require.ErrorAs(t, result.Err, &panicErr)
+				assert.Equal(t, "negative value", panicErr.Value)
+				assert.NotEmpty(t, panicErr.Stack)
+			}
+			assert.Equal(t, 1, pool.Size()) // the pool keeps running
+
+			close(jobsChan)
+			pool.Wait()
+		})
+	}
+}
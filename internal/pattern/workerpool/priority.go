@@ -0,0 +1,155 @@
+package workerpool
+
+import (
+	"container/heap"
+	"context"
+	"time"
+)
+
+// PriorityJob is a job with a scheduling priority and an optional deadline.
+type PriorityJob[T any] struct {
+	Job[T]
+	Priority int       // jobs with higher priority are served first
+	Deadline time.Time // zero means no deadline, see CreatePriorityWorkerPool
+}
+
+// CreatePriorityWorkerPool creates a pool of workers that always picks the most urgent pending job.
+// Jobs are ordered by priority, then by the earliest deadline, then by arrival.
+// A job whose deadline has passed by the time a worker takes it fails with context.DeadlineExceeded without being
+// processed, a running job has its context cancelled at its deadline. Pending jobs don't fail before a worker is free.
+// Use WithAging to raise the priority of waiting jobs over time, so low-priority jobs are not starved.
+func CreatePriorityWorkerPool[T any, U any](ctx context.Context, numWorkers int, jobs <-chan PriorityJob[T], results chan<- Result[T, U], process ProcessFunc[T, U], opts ...Option) *WorkerPool[T, U] {
+	p := newWorkerPool(ctx, results, process, opts)
+	go p.dispatchPriority(jobs)
+	p.Resize(numWorkers)
+
+	return p
+}
+
+// dispatchPriority queues the received jobs and hands the most urgent one to the next free worker.
+// If results are ordered, they are released in the order the jobs were handed to the workers.
+func (p *WorkerPool[T, U]) dispatchPriority(jobs <-chan PriorityJob[T]) {
+	defer p.closeTasks()
+
+	var seq reservation
+	defer p.unreserve(&seq)
+
+	queue := &priorityQueue[T]{aging: p.cfg.aging, start: time.Now()}
+	defer func() {
+		// Keep the jobs that were never handed to a worker, most urgent first.
+		for queue.Len() > 0 {
+			item, _ := heap.Pop(queue).(queuedJob[T])
+			p.addLeftover(item.Job)
+		}
+	}()
+
+	draining := p.draining
+	for jobs != nil || queue.Len() > 0 {
+		// Only offer a task when there is one, a nil channel blocks forever.
+		var (
+			tasks chan<- task[T]
+			next  task[T]
+		)
+		if queue.Len() > 0 {
+			if !p.reserve(&seq) {
+				return
+			}
+			tasks = p.tasks
+			next = queue.peek()
+			next.seq = seq.seq
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-p.done:
+			return
+		case <-draining:
+			takeBuffered(jobs, func(job PriorityJob[T]) {
+				p.accept(job.Job)
+				heap.Push(queue, job)
+			})
+			jobs, draining = nil, nil // no more jobs are accepted, drain the queue
+		case job, ok := <-jobs:
+			if !ok {
+				jobs = nil // jobs channel closed, drain the queue
+				continue
+			}
+			p.accept(job.Job)
+			heap.Push(queue, job)
+		case tasks <- next:
+			heap.Pop(queue)
+			seq.held = false
+		}
+	}
+}
+
+// queuedJob is a priority job waiting in the priority queue.
+type queuedJob[T any] struct {
+	PriorityJob[T]
+	seq    uint64    // arrival order
+	queued time.Time // arrival time
+	score  int       // priority including aging
+}
+
+// priorityQueue implements heap.Interface, the most urgent job is at the top.
+type priorityQueue[T any] struct {
+	items []queuedJob[T]
+	aging time.Duration
+	start time.Time
+	seq   uint64
+}
+
+func (q *priorityQueue[T]) Len() int { return len(q.items) }
+
+func (q *priorityQueue[T]) Less(i, j int) bool {
+	a, b := q.items[i], q.items[j]
+	if a.score != b.score {
+		return a.score > b.score
+	}
+	if !a.Deadline.Equal(b.Deadline) {
+		switch {
+		case a.Deadline.IsZero():
+			return false
+		case b.Deadline.IsZero():
+			return true
+		default:
+			return a.Deadline.Before(b.Deadline)
+		}
+	}
+	return a.seq < b.seq
+}
+
+func (q *priorityQueue[T]) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+
+func (q *priorityQueue[T]) Push(x any) {
+	job, _ := x.(PriorityJob[T])
+	q.seq++
+	q.items = append(q.items, queuedJob[T]{PriorityJob: job, seq: q.seq, queued: time.Now(), score: q.score(job)})
+}
+
+func (q *priorityQueue[T]) Pop() any {
+	last := len(q.items) - 1
+	item := q.items[last]
+	q.items = q.items[:last]
+	return item
+}
+
+// peek returns the most urgent job as a task.
+func (q *priorityQueue[T]) peek() task[T] {
+	return task[T]{job: q.items[0].Job, deadline: q.items[0].Deadline, queued: q.items[0].queued}
+}
+
+// score returns the priority of a job arriving now.
+// With aging every job gains one priority level per aging interval spent waiting,
+// since all waiting jobs age at the same rate this is equivalent to penalising later arrivals,
+// so the score is fixed at arrival and the heap order stays valid.
+// Arrivals are penalised per whole interval, so jobs of the same priority arriving in the same interval tie
+// and are still ordered by deadline.
+func (q *priorityQueue[T]) score(job PriorityJob[T]) int {
+	score := job.Priority
+	if q.aging > 0 {
+		score -= int(time.Since(q.start) / q.aging)
+	}
+	return score
+}
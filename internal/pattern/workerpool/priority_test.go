@@ -0,0 +1,132 @@
+package workerpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityWorkerPool(t *testing.T) {
+	type testCase struct {
+		name    string
+		opts    []Option
+		jobs    []PriorityJob[int]
+		delay   time.Duration // delay between sending jobs
+		wantIDs []int
+	}
+
+	now := time.Now()
+	tests := []testCase{
+		{
+			name: "Highest Priority First",
+			jobs: []PriorityJob[int]{
+				{Job: Job[int]{ID: 1, Value: 1}, Priority: 1},
+				{Job: Job[int]{ID: 2, Value: 2}, Priority: 3},
+				{Job: Job[int]{ID: 3, Value: 3}, Priority: 2},
+			},
+			wantIDs: []int{2, 3, 1},
+		},
+		{
+			name: "Earliest Deadline First",
+			jobs: []PriorityJob[int]{
+				{Job: Job[int]{ID: 1, Value: 1}},
+				{Job: Job[int]{ID: 2, Value: 2}, Deadline: now.Add(time.Hour)},
+				{Job: Job[int]{ID: 3, Value: 3}, Deadline: now.Add(time.Minute)},
+			},
+			wantIDs: []int{3, 2, 1},
+		},
+		{
+			name: "Arrival Order",
+			jobs: []PriorityJob[int]{
+				{Job: Job[int]{ID: 1, Value: 1}},
+				{Job: Job[int]{ID: 2, Value: 2}},
+				{Job: Job[int]{ID: 3, Value: 3}},
+			},
+			wantIDs: []int{1, 2, 3},
+		},
+		{
+			name: "Aging",
+			opts: []Option{WithAging(5 * time.Millisecond)},
+			jobs: []PriorityJob[int]{
+				{Job: Job[int]{ID: 1, Value: 1}, Priority: 0},
+				{Job: Job[int]{ID: 2, Value: 2}, Priority: 1},
+				{Job: Job[int]{ID: 3, Value: 3}, Priority: 2},
+			},
+			delay:   50 * time.Millisecond,
+			wantIDs: []int{1, 2, 3},
+		},
+		{
+			name: "Earliest Deadline First With Aging",
+			opts: []Option{WithAging(time.Hour)},
+			jobs: []PriorityJob[int]{
+				{Job: Job[int]{ID: 1, Value: 1}},
+				{Job: Job[int]{ID: 2, Value: 2}, Deadline: now.Add(time.Minute)},
+			},
+			wantIDs: []int{2, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			started, release := make(chan struct{}), make(chan struct{})
+			process := func(_ context.Context, value int) (int, error) {
+				if value == 0 {
+					close(started)
+					<-release // keep the only worker busy until all jobs are queued
+				}
+				return value, nil
+			}
+
+			jobsChan := make(chan PriorityJob[int]) // unbuffered, so every sent job is queued by the pool
+			resultsChan := make(chan Result[int, int], len(tt.jobs)+1)
+			CreatePriorityWorkerPool(ctx, 1, jobsChan, resultsChan, process, tt.opts...)
+
+			jobsChan <- PriorityJob[int]{Job: Job[int]{ID: 0, Value: 0}}
+			<-started
+			for _, job := range tt.jobs {
+				time.Sleep(tt.delay)
+				jobsChan <- job
+			}
+			close(jobsChan)
+			close(release)
+
+			var gotIDs []int
+			for result := range resultsChan {
+				assert.NoError(t, result.Err)
+				gotIDs = append(gotIDs, result.Job.ID)
+			}
+			assert.Equal(t, append([]int{0}, tt.wantIDs...), gotIDs)
+		})
+	}
+}
+
+func TestPriorityWorkerPoolDeadline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	jobsChan := make(chan PriorityJob[int], 2)
+	resultsChan := make(chan Result[int, int], 2)
+	CreatePriorityWorkerPool(ctx, 1, jobsChan, resultsChan, func(ctx context.Context, value int) (int, error) {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+		return value, nil
+	})
+
+	jobsChan <- PriorityJob[int]{Job: Job[int]{ID: 1, Value: 1}, Deadline: time.Now().Add(-time.Second)}
+	jobsChan <- PriorityJob[int]{Job: Job[int]{ID: 2, Value: 2}, Deadline: time.Now().Add(time.Hour)}
+	close(jobsChan)
+
+	var gotResults []Result[int, int]
+	for result := range resultsChan {
+		gotResults = append(gotResults, result)
+	}
+	assert.ElementsMatch(t, []Result[int, int]{
+		{Job: Job[int]{ID: 1, Value: 1}, Err: context.DeadlineExceeded},
+		{Job: Job[int]{ID: 2, Value: 2}, Value: 2, Attempts: 1},
+	}, gotResults)
+}
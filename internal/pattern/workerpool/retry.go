@@ -0,0 +1,66 @@
+package workerpool
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy defines how failed jobs are retried.
+// The zero value disables retries.
+type RetryPolicy struct {
+	MaxAttempts    int              // total number of attempts, values below 2 disable retries
+	InitialBackoff time.Duration    // delay before the first retry
+	MaxBackoff     time.Duration    // upper bound of the delay between retries, zero means no bound
+	Multiplier     float64          // backoff growth factor between retries, defaults to 2
+	Jitter         float64          // fraction of the delay, between 0 and 1, that is randomly removed
+	Retryable      func(error) bool // reports whether an error is worth retrying, nil retries every error
+}
+
+// shouldRetry reports whether another attempt should follow the given failed attempt.
+func (r RetryPolicy) shouldRetry(attempt int, err error) bool {
+	if attempt >= r.MaxAttempts {
+		return false
+	}
+	return r.Retryable == nil || r.Retryable(err)
+}
+
+// backoff returns the delay to wait after the given failed attempt.
+func (r RetryPolicy) backoff(attempt int) time.Duration {
+	multiplier := r.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	delay := float64(r.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		delay *= multiplier
+		if r.MaxBackoff > 0 && delay >= float64(r.MaxBackoff) {
+			break
+		}
+	}
+	if r.MaxBackoff > 0 {
+		delay = min(delay, float64(r.MaxBackoff))
+	}
+	if jitter := min(max(r.Jitter, 0), 1); jitter > 0 {
+		delay -= delay * jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+// sleep waits for the given delay, it returns false if the context is done first.
+func sleep(ctx context.Context, delay time.Duration) bool {
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,110 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var ErrTemporary = errors.New("temporary error")
+
+// flaky returns a ProcessFunc that fails the first failures attempts of every value.
+func flaky(failures int) ProcessFunc[int, int] {
+	var mu sync.Mutex
+	attempts := map[int]int{}
+	return func(_ context.Context, value int) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		attempts[value]++
+		if attempts[value] <= failures {
+			return 0, ErrTemporary
+		}
+		return value * value, nil
+	}
+}
+
+func TestWorkerPoolRetry(t *testing.T) {
+	type testCase struct {
+		name    string
+		process ProcessFunc[int, int]
+		opts    []Option
+		want    Result[int, int]
+	}
+
+	job := Job[int]{ID: 1, Value: 3}
+	tests := []testCase{
+		{
+			name:    "No Retry Policy",
+			process: flaky(1),
+			want:    Result[int, int]{Job: job, Err: ErrTemporary, Attempts: 1, Errors: []error{ErrTemporary}},
+		},
+		{
+			name:    "Succeeds After Retries",
+			process: flaky(2),
+			opts:    []Option{WithRetryPolicy(RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Jitter: 0.5})},
+			want:    Result[int, int]{Job: job, Value: 9, Attempts: 3, Errors: []error{ErrTemporary, ErrTemporary}},
+		},
+		{
+			name:    "Attempts Exhausted",
+			process: flaky(5),
+			opts:    []Option{WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})},
+			want:    Result[int, int]{Job: job, Err: ErrTemporary, Attempts: 2, Errors: []error{ErrTemporary, ErrTemporary}},
+		},
+		{
+			name:    "Not Retryable",
+			process: flaky(1),
+			opts: []Option{WithRetryPolicy(RetryPolicy{
+				MaxAttempts: 3,
+				Retryable:   func(err error) bool { return !errors.Is(err, ErrTemporary) },
+			})},
+			want: Result[int, int]{Job: job, Err: ErrTemporary, Attempts: 1, Errors: []error{ErrTemporary}},
+		},
+		{
+			name: "Job Timeout",
+			process: func(ctx context.Context, _ int) (int, error) {
+				<-ctx.Done()
+				return 0, ctx.Err()
+			},
+			opts: []Option{
+				WithJobTimeout(10 * time.Millisecond),
+				WithRetryPolicy(RetryPolicy{MaxAttempts: 2}),
+			},
+			want: Result[int, int]{Job: job, Err: context.DeadlineExceeded, Attempts: 2, Errors: []error{context.DeadlineExceeded, context.DeadlineExceeded}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			jobsChan := make(chan Job[int], 1)
+			resultsChan := make(chan Result[int, int], 1)
+			CreateWorkerPool(ctx, 1, jobsChan, resultsChan, tt.process, tt.opts...)
+
+			jobsChan <- job
+			close(jobsChan)
+
+			assert.Equal(t, tt.want, <-resultsChan)
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond, Multiplier: 3}
+
+	assert.Equal(t, 10*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 30*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 50*time.Millisecond, policy.backoff(3))
+	assert.Equal(t, 50*time.Millisecond, policy.backoff(10))
+
+	policy.Jitter = 1
+	for attempt := 1; attempt <= 5; attempt++ {
+		assert.LessOrEqual(t, policy.backoff(attempt), 50*time.Millisecond)
+	}
+}
@@ -0,0 +1,166 @@
+package workerpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingProcess returns a ProcessFunc that blocks on value 0 until release is closed.
+func blockingProcess(started, release chan struct{}) ProcessFunc[int, int] {
+	return func(_ context.Context, value int) (int, error) {
+		if value == 0 {
+			close(started)
+			<-release
+		}
+		return value, nil
+	}
+}
+
+func TestWorkerPoolShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	started, release := make(chan struct{}), make(chan struct{})
+	jobsChan := make(chan PriorityJob[int]) // unbuffered, so every sent job is queued by the pool
+	resultsChan := make(chan Result[int, int], 4)
+	pool := CreatePriorityWorkerPool(ctx, 1, jobsChan, resultsChan, blockingProcess(started, release))
+
+	jobsChan <- PriorityJob[int]{Job: Job[int]{ID: 0, Value: 0}}
+	<-started
+	for i := 1; i <= 3; i++ {
+		jobsChan <- PriorityJob[int]{Job: Job[int]{ID: i, Value: i}}
+	}
+
+	shutdown := make(chan error)
+	go func() {
+		shutdown <- pool.Shutdown(context.Background())
+	}()
+	close(release)
+	require.NoError(t, <-shutdown)
+
+	var gotIDs []int
+	for result := range resultsChan {
+		gotIDs = append(gotIDs, result.Job.ID)
+	}
+	assert.Equal(t, []int{0, 1, 2, 3}, gotIDs) // queued jobs are finished
+	assert.Empty(t, pool.Stop())
+}
+
+func TestWorkerPoolShutdownTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	started, release := make(chan struct{}), make(chan struct{})
+	jobsChan := make(chan PriorityJob[int]) // unbuffered, so every sent job is queued by the pool
+	resultsChan := make(chan Result[int, int], 4)
+	pool := CreatePriorityWorkerPool(ctx, 1, jobsChan, resultsChan, blockingProcess(started, release))
+
+	jobsChan <- PriorityJob[int]{Job: Job[int]{ID: 0, Value: 0}}
+	<-started
+	for i := 1; i <= 3; i++ {
+		jobsChan <- PriorityJob[int]{Job: Job[int]{ID: i, Value: i}, Priority: i}
+	}
+
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer shutdownCancel()
+	require.ErrorIs(t, pool.Shutdown(shutdownCtx), context.DeadlineExceeded)
+
+	// Queued jobs are returned most urgent first.
+	assert.Equal(t, []Job[int]{{ID: 3, Value: 3}, {ID: 2, Value: 2}, {ID: 1, Value: 1}}, pool.Stop())
+	assert.Empty(t, pool.Stop())
+
+	close(release) // the in-flight job still finishes
+	pool.Wait()
+	assert.Equal(t, Result[int, int]{Job: Job[int]{ID: 0, Value: 0}, Attempts: 1}, <-resultsChan)
+}
+
+func TestWorkerPoolStopLeftover(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	started, release := make(chan struct{}), make(chan struct{})
+	jobsChan := make(chan Job[int]) // unbuffered, so every sent job is taken by the pool
+	resultsChan := make(chan Result[int, int], 2)
+	pool := CreateWorkerPool(ctx, 1, jobsChan, resultsChan, blockingProcess(started, release))
+
+	jobsChan <- Job[int]{ID: 0, Value: 0}
+	<-started
+	jobsChan <- Job[int]{ID: 1, Value: 1} // taken by the pool, waiting for a free worker
+
+	assert.Equal(t, []Job[int]{{ID: 1, Value: 1}}, pool.Stop())
+
+	close(release)
+	pool.Wait()
+	assert.Len(t, resultsChan, 1)
+}
+
+func TestWorkerPoolShutdownBufferedJobs(t *testing.T) {
+	tests := []struct {
+		name         string
+		shutdownCtx  func() (context.Context, context.CancelFunc)
+		wantErr      error
+		wantIDs      []int
+		wantLeftover []Job[int]
+	}{
+		{
+			name: "Buffered jobs are processed",
+			shutdownCtx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			wantIDs: []int{0, 1, 2, 3, 4},
+		},
+		{
+			name: "Buffered jobs are returned by Stop",
+			shutdownCtx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 20*time.Millisecond)
+			},
+			wantErr:      context.DeadlineExceeded,
+			wantIDs:      []int{0},
+			wantLeftover: []Job[int]{{ID: 1, Value: 1}, {ID: 2, Value: 2}, {ID: 3, Value: 3}, {ID: 4, Value: 4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel() // ensure resources are cleaned up
+
+			started, release := make(chan struct{}), make(chan struct{})
+			jobsChan := make(chan Job[int], 5)
+			resultsChan := make(chan Result[int, int], 5)
+			pool := CreateWorkerPool(ctx, 1, jobsChan, resultsChan, blockingProcess(started, release))
+
+			jobsChan <- Job[int]{ID: 0, Value: 0}
+			<-started
+			for i := 1; i <= 4; i++ {
+				jobsChan <- Job[int]{ID: i, Value: i} // buffered in the jobs channel
+			}
+
+			shutdownCtx, shutdownCancel := tt.shutdownCtx()
+			defer shutdownCancel()
+			shutdown := make(chan error)
+			go func() {
+				shutdown <- pool.Shutdown(shutdownCtx)
+			}()
+			if tt.wantErr == nil {
+				close(release)
+			}
+			assert.ErrorIs(t, <-shutdown, tt.wantErr)
+			assert.Equal(t, tt.wantLeftover, pool.Stop())
+			if tt.wantErr != nil {
+				close(release) // the in-flight job still finishes
+			}
+
+			var gotIDs []int
+			for result := range resultsChan {
+				gotIDs = append(gotIDs, result.Job.ID)
+			}
+			assert.Equal(t, tt.wantIDs, gotIDs)
+			assert.Empty(t, jobsChan)
+		})
+	}
+}
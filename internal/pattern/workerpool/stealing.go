@@ -0,0 +1,302 @@
+package workerpool
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/errmode"
+)
+
+// WorkStealingPool is a handle to a running pool of workers that each own a deque of jobs.
+type WorkStealingPool[T any, U any] struct {
+	runner[T, U]
+	ctx     context.Context
+	results chan<- Result[T, U]
+	deques  []*deque[T] // one deque per worker
+	cancels cancels
+	errs    *errmode.Collector
+
+	pending     atomic.Int64  // jobs accepted but not finished, including submitted ones
+	queued      atomic.Int64  // jobs waiting in the deques
+	idle        atomic.Int64  // workers parked waiting for jobs
+	steals      atomic.Uint64 // jobs taken from another worker's deque
+	inputClosed atomic.Bool   // set once no more jobs are read from the jobs channel
+
+	mu       sync.Mutex
+	wake     *sync.Cond // signalled when a job is queued or the pool finishes
+	finished bool       // no more jobs will be processed
+
+	wg     sync.WaitGroup
+	closed chan struct{} // closed after the results channel is closed
+}
+
+// CreateWorkStealingPool creates a pool of workers that each own a deque of jobs, instead of sharing one channel.
+// Jobs from the jobs channel are spread over the deques, and jobs submitted with Submit while processing a job
+// are added to the deque of the worker processing it. A worker takes the newest job from its own deque,
+// and steals the oldest job from another worker's deque when its own is empty.
+// The results channel is closed once the jobs channel is closed and all jobs, including submitted ones, are processed,
+// or once the context is cancelled.
+// Results are delivered as they complete, WithOrderedResults and WithRestartOnPanic have no effect.
+func CreateWorkStealingPool[T any, U any](ctx context.Context, numWorkers int, jobs <-chan Job[T], results chan<- Result[T, U], process ProcessFunc[T, U], opts ...Option) *WorkStealingPool[T, U] {
+	cfg := newConfig(opts)
+	ctx, errs := errmode.New(ctx, cfg.errorMode, cfg.errorReport)
+
+	p := &WorkStealingPool[T, U]{
+		runner:  runner[T, U]{process: process, cfg: cfg},
+		ctx:     ctx,
+		errs:    errs,
+		results: results,
+		deques:  make([]*deque[T], max(numWorkers, 1)),
+		closed:  make(chan struct{}),
+	}
+	p.wake = sync.NewCond(&p.mu)
+
+	for i := range p.deques {
+		p.deques[i] = &deque[T]{}
+	}
+	for i := range p.deques {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.worker(i)
+		}()
+	}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.feed(jobs)
+	}()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.finish()
+		case <-p.closed:
+		}
+	}()
+	go func() {
+		p.wg.Wait()
+		p.dropQueued()
+		p.errs.Finish()
+		close(results)
+		close(p.closed)
+	}()
+
+	return p
+}
+
+// Steals returns the number of jobs workers have stolen from each other so far.
+func (p *WorkStealingPool[T, U]) Steals() uint64 {
+	return p.steals.Load()
+}
+
+// Cancel cancels the job with the given ID without affecting other jobs, as WorkerPool.Cancel does.
+func (p *WorkStealingPool[T, U]) Cancel(id int) {
+	p.cancels.cancel(id)
+}
+
+// Wait blocks until all workers have exited and the results channel is closed.
+func (p *WorkStealingPool[T, U]) Wait() {
+	<-p.closed
+}
+
+// submitKey is the context key of the function that adds jobs to the deque of the worker processing a job.
+type submitKey struct{}
+
+// Submit adds a job to the work-stealing pool processing the job that the context was passed to,
+// so recursive workloads such as tree walks can fan out from inside a ProcessFunc.
+// It returns false if the context does not belong to a job of a work-stealing pool with jobs of type T.
+func Submit[T any](ctx context.Context, job Job[T]) bool {
+	submit, ok := ctx.Value(submitKey{}).(func(Job[T]))
+	if !ok {
+		return false
+	}
+	submit(job)
+	return true
+}
+
+// feed spreads the jobs from the jobs channel over the deques of the workers.
+func (p *WorkStealingPool[T, U]) feed(jobs <-chan Job[T]) {
+	defer func() {
+		p.inputClosed.Store(true)
+		if p.pending.Load() == 0 {
+			p.finish()
+		}
+	}()
+
+	for i := 0; ; i = (i + 1) % len(p.deques) {
+		select {
+		case <-p.ctx.Done():
+			return
+		case job, ok := <-jobs:
+			if !ok {
+				return // jobs channel closed, finish once all jobs are processed
+			}
+			p.push(p.deques[i], job)
+		}
+	}
+}
+
+// push adds a job to a deque and wakes a parked worker to take it.
+func (p *WorkStealingPool[T, U]) push(d *deque[T], job Job[T]) {
+	p.pending.Add(1)
+	p.cfg.metrics.JobQueued()
+	p.cancels.accept(job.ID)
+
+	p.queued.Add(1) // counted before the push, so a parking worker never misses it
+	d.pushBack(task[T]{job: job, queued: time.Now()})
+
+	if p.idle.Load() > 0 {
+		p.mu.Lock()
+		p.wake.Signal()
+		p.mu.Unlock()
+	}
+}
+
+// finish marks the pool as finished and wakes all parked workers, so they exit.
+func (p *WorkStealingPool[T, U]) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.finished = true
+	p.wake.Broadcast()
+}
+
+// worker processes jobs from its own deque, or stolen from others, until the pool finishes.
+func (p *WorkStealingPool[T, U]) worker(id int) {
+	own := p.deques[id]
+	ctx := context.WithValue(p.ctx, submitKey{}, func(job Job[T]) {
+		p.push(own, job)
+	})
+
+	for {
+		t, ok := p.take(id)
+		if !ok {
+			return
+		}
+		start := time.Now()
+		p.cfg.metrics.JobStarted(start.Sub(t.queued))
+		result := p.runUnlessCancelled(ctx, &p.cancels, t)
+		p.cfg.metrics.JobFinished(time.Since(start), result.Err)
+		recordUnlessCancelled(p.errs, t.job.ID, result.Err)
+
+		p.results <- result
+		if p.pending.Add(-1) == 0 && p.inputClosed.Load() {
+			p.finish() // no job left that could submit more
+		}
+	}
+}
+
+// dropQueued reports the jobs left in the deques once the context is cancelled as dropped.
+func (p *WorkStealingPool[T, U]) dropQueued() {
+	for _, d := range p.deques {
+		for {
+			if _, ok := d.popFront(); !ok {
+				break
+			}
+			p.queued.Add(-1)
+			p.cfg.metrics.JobDropped()
+		}
+	}
+}
+
+// take returns the next job for a worker, parking the worker while there is none.
+// It returns false once the pool finishes or the context is cancelled.
+func (p *WorkStealingPool[T, U]) take(id int) (task[T], bool) {
+	for {
+		if p.ctx.Err() != nil {
+			return task[T]{}, false
+		}
+		if t, ok := p.deques[id].popBack(); ok {
+			p.queued.Add(-1)
+			return t, true
+		}
+		if t, ok := p.steal(id); ok {
+			p.queued.Add(-1)
+			p.steals.Add(1)
+			return t, true
+		}
+
+		p.mu.Lock()
+		if p.finished {
+			p.mu.Unlock()
+			return task[T]{}, false
+		}
+		p.idle.Add(1)
+		if p.queued.Load() == 0 {
+			p.wake.Wait()
+		}
+		p.idle.Add(-1)
+		p.mu.Unlock()
+	}
+}
+
+// steal takes the oldest job from the deque of another worker, starting at a random one to spread contention.
+func (p *WorkStealingPool[T, U]) steal(id int) (task[T], bool) {
+	n := len(p.deques)
+	start := rand.IntN(n)
+	for i := 0; i < n; i++ {
+		victim := (start + i) % n
+		if victim == id {
+			continue
+		}
+		if t, ok := p.deques[victim].popFront(); ok {
+			return t, true
+		}
+	}
+	return task[T]{}, false
+}
+
+// deque is a double-ended queue of tasks, its owner works on the back and thieves steal from the front.
+type deque[T any] struct {
+	mu    sync.Mutex
+	tasks []task[T]
+	head  int // index of the front task
+}
+
+func (d *deque[T]) pushBack(t task[T]) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.tasks = append(d.tasks, t)
+}
+
+func (d *deque[T]) popBack() (task[T], bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.head == len(d.tasks) {
+		return task[T]{}, false
+	}
+	last := len(d.tasks) - 1
+	t := d.tasks[last]
+	d.tasks[last] = task[T]{}
+	d.tasks = d.tasks[:last]
+	d.reset()
+	return t, true
+}
+
+func (d *deque[T]) popFront() (task[T], bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.head == len(d.tasks) {
+		return task[T]{}, false
+	}
+	t := d.tasks[d.head]
+	d.tasks[d.head] = task[T]{}
+	d.head++
+	d.reset()
+	return t, true
+}
+
+// reset reuses the backing array once the deque is empty.
+func (d *deque[T]) reset() {
+	if d.head == len(d.tasks) {
+		d.tasks = d.tasks[:0]
+		d.head = 0
+	}
+}
@@ -0,0 +1,149 @@
+package workerpool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+// fineGrainedJobs is the number of jobs per benchmark iteration, each doing a tiny amount of work.
+const fineGrainedJobs = 10_000
+
+func BenchmarkWorkerPoolFineGrained(b *testing.B) {
+	benchmarkFineGrained(b, func(ctx context.Context, jobs <-chan Job[int], results chan<- Result[int, int], process ProcessFunc[int, int]) {
+		CreateWorkerPool(ctx, runtime.GOMAXPROCS(0), jobs, results, process)
+	})
+}
+
+func BenchmarkWorkStealingPoolFineGrained(b *testing.B) {
+	benchmarkFineGrained(b, func(ctx context.Context, jobs <-chan Job[int], results chan<- Result[int, int], process ProcessFunc[int, int]) {
+		CreateWorkStealingPool(ctx, runtime.GOMAXPROCS(0), jobs, results, process)
+	})
+}
+
+func benchmarkFineGrained(b *testing.B, create func(context.Context, <-chan Job[int], chan<- Result[int, int], ProcessFunc[int, int])) {
+	ctx := context.Background()
+	process := func(_ context.Context, value int) (int, error) {
+		return value * value, nil
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		jobsChan := make(chan Job[int], fineGrainedJobs)
+		resultsChan := make(chan Result[int, int], fineGrainedJobs)
+		create(ctx, jobsChan, resultsChan, process)
+
+		for j := 0; j < fineGrainedJobs; j++ {
+			jobsChan <- Job[int]{ID: j, Value: j}
+		}
+		close(jobsChan)
+		for range resultsChan {
+		}
+	}
+}
+
+// BenchmarkWorkerPoolTreeWalk walks a file tree with a shared jobs channel,
+// the subdirectories found by a job are fed back into the channel by the results loop.
+func BenchmarkWorkerPoolTreeWalk(b *testing.B) {
+	root := createTree(b, 4, 5)
+	ctx := context.Background()
+	process := func(_ context.Context, dir string) ([]string, error) {
+		return readDir(dir)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		jobsChan := make(chan Job[string], 1024)
+		resultsChan := make(chan Result[string, []string], 1024)
+		CreateWorkerPool(ctx, runtime.GOMAXPROCS(0), jobsChan, resultsChan, process)
+
+		var queue []string // subdirectories waiting to be sent, so the results loop never blocks on the jobs channel
+		outstanding := 1
+		jobsChan <- Job[string]{Value: root}
+		for outstanding > 0 {
+			var send chan<- Job[string]
+			var next Job[string]
+			if len(queue) > 0 {
+				send, next = jobsChan, Job[string]{Value: queue[0]}
+			}
+
+			select {
+			case send <- next:
+				queue = queue[1:]
+			case result := <-resultsChan:
+				outstanding += len(result.Value) - 1
+				queue = append(queue, result.Value...)
+			}
+		}
+		close(jobsChan)
+	}
+}
+
+// BenchmarkWorkStealingPoolTreeWalk walks a file tree with a work-stealing pool,
+// every job submits the subdirectories it finds to its own worker's deque.
+func BenchmarkWorkStealingPoolTreeWalk(b *testing.B) {
+	root := createTree(b, 4, 5)
+	ctx := context.Background()
+	process := func(ctx context.Context, dir string) ([]string, error) {
+		subdirs, err := readDir(dir)
+		for _, subdir := range subdirs {
+			Submit(ctx, Job[string]{Value: subdir})
+		}
+		return subdirs, err
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		jobsChan := make(chan Job[string], 1)
+		resultsChan := make(chan Result[string, []string], 1024)
+		CreateWorkStealingPool(ctx, runtime.GOMAXPROCS(0), jobsChan, resultsChan, process)
+
+		jobsChan <- Job[string]{Value: root}
+		close(jobsChan)
+		for range resultsChan {
+		}
+	}
+}
+
+// createTree creates a directory tree of the given depth, where every directory has fanout subdirectories and files.
+func createTree(b *testing.B, depth, fanout int) string {
+	root := b.TempDir()
+
+	var create func(dir string, depth int)
+	create = func(dir string, depth int) {
+		for i := 0; i < fanout; i++ {
+			name := strconv.Itoa(i)
+			if err := os.WriteFile(filepath.Join(dir, name+".txt"), nil, 0o644); err != nil {
+				b.Fatal(err)
+			}
+			if depth > 0 {
+				subdir := filepath.Join(dir, name)
+				if err := os.Mkdir(subdir, 0o755); err != nil {
+					b.Fatal(err)
+				}
+				create(subdir, depth-1)
+			}
+		}
+	}
+	create(root, depth)
+	return root
+}
+
+// readDir returns the subdirectories of a directory.
+func readDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var subdirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			subdirs = append(subdirs, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return subdirs, nil
+}
@@ -0,0 +1,117 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkStealingPool(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	const numJobs = 100
+	jobsChan := make(chan Job[int], numJobs)
+	resultsChan := make(chan Result[int, int], numJobs)
+	process := func(_ context.Context, value int) (int, error) {
+		return value * 2, nil
+	}
+	CreateWorkStealingPool(ctx, 4, jobsChan, resultsChan, process)
+
+	for i := 0; i < numJobs; i++ {
+		jobsChan <- Job[int]{ID: i, Value: i}
+	}
+	close(jobsChan)
+
+	var got []int
+	for result := range resultsChan {
+		assert.NoError(t, result.Err)
+		assert.Equal(t, 1, result.Attempts)
+		got = append(got, result.Value)
+	}
+	sort.Ints(got)
+
+	var want []int
+	for i := 0; i < numJobs; i++ {
+		want = append(want, i*2)
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestWorkStealingPoolSubmit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	// Every node below the maximum depth submits three children, a complete tree of depth 4 has 121 nodes.
+	const maxDepth, children = 4, 3
+	process := func(ctx context.Context, depth int) (int, error) {
+		if depth < maxDepth {
+			for i := 0; i < children; i++ {
+				assert.True(t, Submit(ctx, Job[int]{Value: depth + 1}))
+			}
+		}
+		time.Sleep(100 * time.Microsecond)
+		return depth, nil
+	}
+
+	jobsChan := make(chan Job[int], 1)
+	resultsChan := make(chan Result[int, int])
+	pool := CreateWorkStealingPool(ctx, 4, jobsChan, resultsChan, process)
+
+	jobsChan <- Job[int]{Value: 0}
+	close(jobsChan)
+
+	perDepth := map[int]int{}
+	for result := range resultsChan {
+		perDepth[result.Value]++
+	}
+	assert.Equal(t, map[int]int{0: 1, 1: 3, 2: 9, 3: 27, 4: 81}, perDepth)
+	assert.Positive(t, pool.Steals()) // the children of the root are only reachable by stealing
+	assert.False(t, Submit(ctx, Job[int]{}))
+}
+
+func TestWorkStealingPoolRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	errTransient := errors.New("transient")
+	jobsChan := make(chan Job[int], 1)
+	resultsChan := make(chan Result[int, int], 1)
+	attempts := 0
+	process := func(_ context.Context, value int) (int, error) {
+		attempts++
+		if attempts == 1 {
+			return 0, errTransient
+		}
+		return value, nil
+	}
+	CreateWorkStealingPool(ctx, 1, jobsChan, resultsChan, process, WithRetryPolicy(RetryPolicy{MaxAttempts: 2}))
+
+	jobsChan <- Job[int]{ID: 1, Value: 1}
+	close(jobsChan)
+
+	result := <-resultsChan
+	assert.NoError(t, result.Err)
+	assert.Equal(t, 2, result.Attempts)
+	assert.Equal(t, []error{errTransient}, result.Errors)
+}
+
+func TestWorkStealingPoolCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	jobsChan := make(chan Job[int])
+	resultsChan := make(chan Result[int, int])
+	process := func(_ context.Context, value int) (int, error) {
+		return value, nil
+	}
+	pool := CreateWorkStealingPool(ctx, 4, jobsChan, resultsChan, process)
+
+	cancel()
+	pool.Wait()
+	_, ok := <-resultsChan
+	assert.False(t, ok)
+}
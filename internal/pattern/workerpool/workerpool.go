@@ -3,6 +3,11 @@ package workerpool
 import (
 	"context"
 	"sync"
+	"time"
+
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/errmode"
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/panics"
+	"github.com/romangurevitch/concurrencyworkshop/internal/pattern/internal/reorder"
 )
 
 // Job holds information about each job.
@@ -13,43 +18,454 @@ type Job[T any] struct {
 
 // Result holds information about each result.
 type Result[T any, U any] struct {
-	Job   Job[T]
-	Value U
-	Err   error
+	Job      Job[T]
+	Value    U
+	Err      error   // error of the last attempt
+	Attempts int     // number of times the job was processed
+	Errors   []error // errors of all failed attempts, in order
 }
 
 // ProcessFunc defines a function type for processing a value of type T to produce a value of type U, in a context-aware manner.
 type ProcessFunc[T any, U any] func(context.Context, T) (U, error)
 
-// Worker function processes jobs and produces results.
-func worker[T any, U any](ctx context.Context, jobs <-chan Job[T], results chan<- Result[T, U], processFunc ProcessFunc[T, U]) {
-	for {
+// WorkerPool is a handle to a running pool of workers.
+// It allows the number of workers to be changed while the pool is running.
+type WorkerPool[T any, U any] struct {
+	runner[T, U]
+	ctx     context.Context
+	tasks   chan task[T] // jobs handed from the dispatcher to the workers
+	results chan<- Result[T, U]
+	order   *reorder.Buffer[Result[T, U]] // releases results in submission order, nil if results are unordered
+	errs    *errmode.Collector
+
+	afterRun func(task[T]) // called after the result of a task is delivered, may be nil
+	cancels  cancels
+
+	mu       sync.Mutex
+	stops    []chan struct{} // one stop channel per running worker
+	stopped  bool            // no more workers can be started once set
+	leftover []Job[T]        // jobs taken by the pool but never handed to a worker
+
+	wg           sync.WaitGroup
+	done         chan struct{} // closed when the pool stops, in-flight jobs still finish
+	doneOnce     sync.Once
+	draining     chan struct{} // closed when the pool stops accepting jobs, queued jobs still finish
+	drainingOnce sync.Once
+	dispatched   chan struct{} // closed after the dispatcher exits
+	closed       chan struct{} // closed after the results channel is closed
+}
+
+// task is a job accepted by the pool, waiting to be picked up by a worker.
+type task[T any] struct {
+	job      Job[T]
+	deadline time.Time // zero means no deadline
+	seq      uint64    // submission order, only set if results are ordered
+	queued   time.Time // when the pool accepted the job
+	ticket   uint64    // identifies the task to its dispatcher, only set by keyed pools
+}
+
+// CreateWorkerPool creates a pool of workers.
+// The results channel is closed once the jobs channel is closed, the context is cancelled or the pool is stopped,
+// and all workers have finished their in-flight jobs.
+func CreateWorkerPool[T any, U any](ctx context.Context, numWorkers int, jobs <-chan Job[T], results chan<- Result[T, U], process ProcessFunc[T, U], opts ...Option) *WorkerPool[T, U] {
+	p := newWorkerPool(ctx, results, process, opts)
+	go p.dispatch(jobs)
+	p.Resize(numWorkers)
+
+	return p
+}
+
+// newWorkerPool creates a pool without workers, it's up to the caller to start a dispatcher and the workers.
+func newWorkerPool[T any, U any](ctx context.Context, results chan<- Result[T, U], process ProcessFunc[T, U], opts []Option) *WorkerPool[T, U] {
+	cfg := newConfig(opts)
+	ctx, errs := errmode.New(ctx, cfg.errorMode, cfg.errorReport)
+
+	p := &WorkerPool[T, U]{
+		runner:     runner[T, U]{process: process, cfg: cfg},
+		ctx:        ctx,
+		errs:       errs,
+		tasks:      make(chan task[T]),
+		results:    results,
+		done:       make(chan struct{}),
+		draining:   make(chan struct{}),
+		dispatched: make(chan struct{}),
+		closed:     make(chan struct{}),
+	}
+	if p.cfg.orderWindow > 0 {
+		p.order = reorder.New(p.cfg.orderWindow, func(result Result[T, U]) {
+			results <- result
+		})
+	}
+
+	go func() {
 		select {
+		case <-p.done:
 		case <-ctx.Done():
-			return // context cancelled, exit worker
+		}
+		p.terminate()
+
+		p.wg.Wait()
+		p.errs.Finish()
+		close(results)
+		close(p.closed)
+	}()
+
+	return p
+}
+
+// Resize changes the number of workers to n.
+// Removed workers finish their in-flight job before exiting, so no job is lost.
+// A pool with zero workers is paused until it is resized again.
+func (p *WorkerPool[T, U]) Resize(n int) {
+	n = max(n, 0)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopped {
+		return
+	}
+	for len(p.stops) < n {
+		stop := make(chan struct{})
+		p.stops = append(p.stops, stop)
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.worker(stop)
+		}()
+	}
+	for len(p.stops) > n {
+		last := len(p.stops) - 1
+		close(p.stops[last])
+		p.stops = p.stops[:last]
+	}
+}
+
+// Size returns the current number of workers.
+func (p *WorkerPool[T, U]) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.stops)
+}
+
+// Shutdown stops accepting jobs and waits for the in-flight and queued jobs to finish.
+// The jobs buffered in the jobs channel when Shutdown is called count as queued, jobs sent after it are left untouched.
+// If the context is done first, the pool is stopped as with Stop, and the context error is returned.
+// The queued jobs that were not handed to a worker by then, including the buffered ones, are returned by Stop.
+func (p *WorkerPool[T, U]) Shutdown(ctx context.Context) error {
+	p.drainingOnce.Do(func() {
+		close(p.draining)
+	})
+
+	select {
+	case <-p.closed:
+		return nil
+	case <-ctx.Done():
+		p.terminate()
+		return ctx.Err()
+	}
+}
+
+// Stop stops all workers once their in-flight jobs are done,
+// and returns the jobs taken by the pool that were never processed, so they can be persisted or requeued.
+// The jobs are returned only once, by the first call to Stop after the pool stopped.
+// Jobs still in the jobs channel are left untouched.
+func (p *WorkerPool[T, U]) Stop() []Job[T] {
+	p.terminate()
+	<-p.dispatched
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	leftover := p.leftover
+	p.leftover = nil
+	return leftover
+}
+
+// Cancel cancels the job with the given ID without affecting other jobs, its result carries ErrJobCancelled.
+// A job that has not started yet is skipped when it reaches a worker, a running job has its context cancelled.
+// A running job that succeeds before noticing the cancellation keeps its result.
+// A job the pool has not received yet, such as one still buffered in the jobs channel, is skipped once it arrives,
+// up to 1024 such cancellations are remembered, the oldest are forgotten first.
+// Job IDs must be unique for Cancel to target the right job, cancelling a job that is done may skip a later job with its ID.
+func (p *WorkerPool[T, U]) Cancel(id int) {
+	p.cancels.cancel(id)
+}
+
+// Wait blocks until all workers have exited and the results channel is closed.
+func (p *WorkerPool[T, U]) Wait() {
+	<-p.closed
+}
+
+// terminate stops all workers and marks the pool as done, no more workers can be started afterwards.
+func (p *WorkerPool[T, U]) terminate() {
+	p.doneOnce.Do(func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		for _, stop := range p.stops {
+			close(stop)
+		}
+		p.stops = nil
+		p.stopped = true
+		close(p.done)
+	})
+}
+
+// isDone reports whether the pool has stopped.
+func (p *WorkerPool[T, U]) isDone() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// dispatch hands jobs to the workers in the order they are received.
+func (p *WorkerPool[T, U]) dispatch(jobs <-chan Job[T]) {
+	defer p.closeTasks()
+	defer p.keepBuffered(jobs)
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-p.done:
+			return
+		case <-p.draining:
+			p.dispatchBuffered(jobs)
+			return // no more jobs are accepted
 		case job, ok := <-jobs:
 			if !ok {
-				return // jobs channel closed, exit worker
+				return // jobs channel closed, no more tasks
+			}
+			p.accept(job)
+			if !p.handOff(task[T]{job: job, queued: time.Now()}) {
+				return
 			}
-			value, err := processFunc(ctx, job.Value)
-			results <- Result[T, U]{Job: job, Value: value, Err: err}
 		}
 	}
 }
 
-// CreateWorkerPool creates a pool of workers.
-func CreateWorkerPool[T any, U any](ctx context.Context, numWorkers int, jobs <-chan Job[T], results chan<- Result[T, U], process ProcessFunc[T, U]) {
-	wg := sync.WaitGroup{}
-	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			worker(ctx, jobs, results, process)
-		}()
+// dispatchBuffered hands the jobs buffered in the jobs channel when the pool started draining to the workers.
+// If the pool is done first, the jobs that were not handed off yet are kept as leftover.
+func (p *WorkerPool[T, U]) dispatchBuffered(jobs <-chan Job[T]) {
+	stopped := false
+	takeBuffered(jobs, func(job Job[T]) {
+		p.accept(job)
+		if stopped {
+			p.addLeftover(job)
+			return
+		}
+		stopped = !p.handOff(task[T]{job: job, queued: time.Now()})
+	})
+}
+
+// keepBuffered keeps the jobs still buffered in the jobs channel as leftover if the pool stopped while draining,
+// they were queued by the time Shutdown was called.
+func (p *WorkerPool[T, U]) keepBuffered(jobs <-chan Job[T]) {
+	select {
+	case <-p.draining:
+	default:
+		return
+	}
+	if !p.isDone() {
+		return // the buffered jobs were handed off already, or the context was cancelled
 	}
+	takeBuffered(jobs, func(job Job[T]) {
+		p.accept(job)
+		p.addLeftover(job)
+	})
+}
+
+// takeBuffered takes the jobs buffered in a jobs channel right now, without waiting for more.
+func takeBuffered[J any](jobs <-chan J, take func(J)) {
+	for n := len(jobs); n > 0; n-- {
+		select {
+		case job, ok := <-jobs:
+			if !ok {
+				return
+			}
+			take(job)
+		default:
+			return
+		}
+	}
+}
+
+// accept counts a job taken from the jobs channel as queued.
+func (p *WorkerPool[T, U]) accept(job Job[T]) {
+	p.cfg.metrics.JobQueued()
+	p.cancels.accept(job.ID)
+}
+
+// handOff passes a task to the next free worker, assigning it a sequence number if results are ordered.
+// It returns false, keeping the job as leftover, if the pool is done before a worker takes the task.
+func (p *WorkerPool[T, U]) handOff(t task[T]) bool {
+	if p.order != nil {
+		seq, ok := p.order.Acquire(p.ctx, p.done)
+		if !ok {
+			p.addLeftover(t.job)
+			return false
+		}
+		t.seq = seq
+	}
+
+	select {
+	case <-p.ctx.Done():
+	case <-p.done:
+	case p.tasks <- t:
+		return true
+	}
+	if p.order != nil {
+		p.order.Skip(t.seq)
+	}
+	p.addLeftover(t.job)
+	return false
+}
+
+// reservation is a sequence number reserved by a dispatcher for the next task it offers to the workers.
+// Dispatchers that offer tasks while waiting for other events keep the reservation until a worker takes the task.
+type reservation struct {
+	seq  uint64
+	held bool
+}
+
+// reserve reserves a sequence number if results are ordered and none is held yet.
+// It returns false if the pool is done first.
+func (p *WorkerPool[T, U]) reserve(r *reservation) bool {
+	if p.order == nil || r.held {
+		return true
+	}
+	r.seq, r.held = p.order.Acquire(p.ctx, p.done)
+	return r.held
+}
+
+// unreserve gives up a held sequence number, so later results are not held back.
+func (p *WorkerPool[T, U]) unreserve(r *reservation) {
+	if r.held {
+		p.order.Skip(r.seq)
+		r.held = false
+	}
+}
+
+// addLeftover keeps jobs that were taken by the pool but will never be processed, and reports them as dropped.
+func (p *WorkerPool[T, U]) addLeftover(jobs ...Job[T]) {
+	for _, job := range jobs {
+		p.cancels.forget(job.ID)
+		p.cfg.metrics.JobDropped()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.leftover = append(p.leftover, jobs...)
+}
+
+// closeTasks signals the workers that no more tasks will follow and that the dispatcher has exited.
+func (p *WorkerPool[T, U]) closeTasks() {
+	close(p.tasks)
+	close(p.dispatched)
+}
+
+// deliver sends the result of a task, holding it back until its turn if results are ordered.
+func (p *WorkerPool[T, U]) deliver(t task[T], result Result[T, U]) {
+	if p.order != nil {
+		p.order.Release(t.seq, result)
+		return
+	}
+	p.results <- result
+}
 
+// worker processes jobs and produces results until it is stopped.
+func (p *WorkerPool[T, U]) worker(stop <-chan struct{}) {
+	for {
+		select {
+		case <-p.ctx.Done():
+			return // context cancelled, exit worker
+		case <-stop:
+			return // worker removed from the pool, exit worker
+		case t, ok := <-p.tasks:
+			if !ok {
+				p.terminate()
+				return // no more jobs, exit worker
+			}
+			start := time.Now()
+			p.cfg.metrics.JobStarted(start.Sub(t.queued))
+			result := p.runUnlessCancelled(p.ctx, &p.cancels, t)
+			p.cfg.metrics.JobFinished(time.Since(start), result.Err)
+			recordUnlessCancelled(p.errs, t.job.ID, result.Err)
+
+			p.deliver(t, result)
+			if p.afterRun != nil {
+				p.afterRun(t)
+			}
+			if p.cfg.restartOnPanic && panicked(result.Errors) {
+				p.restart(stop)
+				return // replaced by a new worker goroutine
+			}
+		}
+	}
+}
+
+// restart starts a new worker goroutine that takes over the given stop channel.
+func (p *WorkerPool[T, U]) restart(stop <-chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopped {
+		return
+	}
+	p.wg.Add(1)
 	go func() {
-		wg.Wait()
-		close(results)
+		defer p.wg.Done()
+		p.worker(stop)
 	}()
 }
+
+// runner processes tasks according to the pool options.
+type runner[T any, U any] struct {
+	process ProcessFunc[T, U]
+	cfg     config
+}
+
+// run processes a task, retrying failed attempts according to the retry policy.
+// A task whose deadline has already passed is reported without being processed.
+func (r runner[T, U]) run(ctx context.Context, t task[T]) Result[T, U] {
+	result := Result[T, U]{Job: t.job}
+
+	if !t.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, t.deadline)
+		defer cancel()
+
+		if result.Err = ctx.Err(); result.Err != nil {
+			return result
+		}
+	}
+
+	for {
+		result.Attempts++
+		result.Value, result.Err = r.attempt(ctx, t.job)
+		if result.Err == nil {
+			return result
+		}
+		result.Errors = append(result.Errors, result.Err)
+
+		if !r.cfg.retry.shouldRetry(result.Attempts, result.Err) || !sleep(ctx, r.cfg.retry.backoff(result.Attempts)) {
+			return result
+		}
+	}
+}
+
+// attempt processes a job once, bounded by the job timeout if one is set.
+func (r runner[T, U]) attempt(ctx context.Context, job Job[T]) (U, error) {
+	if r.cfg.jobTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.cfg.jobTimeout)
+		defer cancel()
+	}
+	return panics.Process(ctx, r.process, job.Value)
+}
@@ -47,9 +47,9 @@ func TestWorkerPool(t *testing.T) {
 				process: squareNonNegative,
 			},
 			want: []Result[int, int]{
-				{Job: Job[int]{ID: 1, Value: 2}, Value: 4},
-				{Job: Job[int]{ID: 2, Value: 3}, Value: 9},
-				{Job: Job[int]{ID: 3, Value: -1}, Err: ErrNegativeValue},
+				{Job: Job[int]{ID: 1, Value: 2}, Value: 4, Attempts: 1},
+				{Job: Job[int]{ID: 2, Value: 3}, Value: 9, Attempts: 1},
+				{Job: Job[int]{ID: 3, Value: -1}, Err: ErrNegativeValue, Attempts: 1, Errors: []error{ErrNegativeValue}},
 			},
 		},
 		{
@@ -72,8 +72,8 @@ func TestWorkerPool(t *testing.T) {
 				process: squareNonNegative,
 			},
 			want: []Result[int, int]{
-				{Job: Job[int]{ID: 1, Value: 2}, Value: 4},
-				{Job: Job[int]{ID: 2, Value: 3}, Value: 9},
+				{Job: Job[int]{ID: 1, Value: 2}, Value: 4, Attempts: 1},
+				{Job: Job[int]{ID: 2, Value: 3}, Value: 9, Attempts: 1},
 			},
 		},
 		{
@@ -89,10 +89,10 @@ func TestWorkerPool(t *testing.T) {
 				process: squareNonNegative,
 			},
 			want: []Result[int, int]{
-				{Job: Job[int]{ID: 1, Value: 2}, Value: 4},
-				{Job: Job[int]{ID: 2, Value: 3}, Value: 9},
-				{Job: Job[int]{ID: 3, Value: 4}, Value: 16},
-				{Job: Job[int]{ID: 4, Value: 5}, Value: 25},
+				{Job: Job[int]{ID: 1, Value: 2}, Value: 4, Attempts: 1},
+				{Job: Job[int]{ID: 2, Value: 3}, Value: 9, Attempts: 1},
+				{Job: Job[int]{ID: 3, Value: 4}, Value: 16, Attempts: 1},
+				{Job: Job[int]{ID: 4, Value: 5}, Value: 25, Attempts: 1},
 			},
 		},
 		{
@@ -139,3 +139,66 @@ func TestWorkerPool(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkerPoolResize(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	const numJobs = 10
+	started := make(chan int, numJobs)
+	release := make(chan struct{})
+	process := func(_ context.Context, value int) (int, error) {
+		started <- value
+		<-release
+		return value, nil
+	}
+
+	jobsChan := make(chan Job[int], numJobs)
+	resultsChan := make(chan Result[int, int], numJobs)
+	pool := CreateWorkerPool(ctx, 1, jobsChan, resultsChan, process)
+	assert.Equal(t, 1, pool.Size())
+
+	for i := 1; i <= numJobs; i++ {
+		jobsChan <- Job[int]{ID: i, Value: i}
+	}
+	close(jobsChan)
+
+	pool.Resize(3)
+	assert.Equal(t, 3, pool.Size())
+	for i := 0; i < 3; i++ {
+		<-started // three jobs are running concurrently
+	}
+
+	pool.Resize(1) // removed workers finish their in-flight jobs first
+	assert.Equal(t, 1, pool.Size())
+	close(release)
+
+	var gotResults []Result[int, int]
+	for result := range resultsChan {
+		gotResults = append(gotResults, result)
+	}
+	pool.Wait()
+
+	assert.Len(t, gotResults, numJobs)
+	assert.Equal(t, 0, pool.Size())
+}
+
+func TestWorkerPoolStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel() // ensure resources are cleaned up
+
+	jobsChan := make(chan Job[int], 1)
+	resultsChan := make(chan Result[int, int], 1)
+	pool := CreateWorkerPool(ctx, 2, jobsChan, resultsChan, squareNonNegative)
+
+	jobsChan <- Job[int]{ID: 1, Value: 2}
+	assert.Equal(t, Result[int, int]{Job: Job[int]{ID: 1, Value: 2}, Value: 4, Attempts: 1}, <-resultsChan)
+
+	assert.Empty(t, pool.Stop()) // nothing was left unprocessed
+	pool.Wait()
+	pool.Resize(2) // a stopped pool can't be resized
+
+	_, ok := <-resultsChan
+	assert.False(t, ok)
+	assert.Equal(t, 0, pool.Size())
+}